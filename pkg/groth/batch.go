@@ -0,0 +1,219 @@
+package groth
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"strings"
+	"sync"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+)
+
+var ErrBatchVerifyFailed = errors.New("groth batch verification failed")
+
+// batchCoeffBits 随机线性组合系数的比特长度，无效签名通过批量验证的概率不超过2^-128
+const batchCoeffBits = 128
+
+// BatchError 批量验证失败时返回的错误，记录无效签名的下标及其单独验证时的错误
+type BatchError struct {
+	Failed []int
+	Errs   []error // Errs[i]为签名Failed[i]的验证错误
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, idx := range e.Failed {
+		msgs[i] = fmt.Sprintf("index %d: %v", idx, e.Errs[i])
+	}
+
+	return fmt.Sprintf("%v: %s", ErrBatchVerifyFailed, strings.Join(msgs, "; "))
+}
+
+// Unwrap 使 errors.Is 能够匹配 ErrBatchVerifyFailed 以及各签名的验证错误
+func (e *BatchError) Unwrap() []error {
+	return append([]error{ErrBatchVerifyFailed}, e.Errs...)
+}
+
+// BatchVerify 批量验证sigs[i]是否为pks[i]对ms[i]的有效签名。
+// 每个签名的len(m)+1个验证等式以随机系数合并为一个配对乘积，只需一次最终幂运算，
+// 批量验证不通过时逐个验证，并返回记录了无效签名下标的 *BatchError
+func BatchVerify(sp *Parameters, pks []*PK, ms []*Message, sigs []*Signature) error {
+	const prefix = "failed to batch verify groth signatures"
+	if len(pks) != len(sigs) || len(ms) != len(sigs) {
+		return fmt.Errorf(
+			"%s: %w, pks(%d), ms(%d) and sigs(%d) must have the same length",
+			prefix,
+			ErrInconsistentArgLen,
+			len(pks),
+			len(ms),
+			len(sigs),
+		)
+	}
+
+	acc := newVerifyAcc()
+	for i, sig := range sigs {
+		if err := sig.checkArgs(sp, ms[i]); err != nil {
+			return fmt.Errorf("%s: %w, at index %d", prefix, err, i)
+		}
+		if err := acc.add(sp, pks[i], ms[i], sig); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	if utils.IsEProductOne(acc.eArgs()) {
+		return nil
+	}
+
+	be := new(BatchError)
+	for i, sig := range sigs {
+		if err := sig.verifyEach(sp, pks[i], ms[i]); err != nil {
+			be.Failed = append(be.Failed, i)
+			be.Errs = append(be.Errs, err)
+		}
+	}
+	if len(be.Failed) == 0 {
+		// 仅当随机系数的选取极不走运时才会发生
+		return fmt.Errorf("%s: %w", prefix, ErrBatchVerifyFailed)
+	}
+
+	return be
+}
+
+// verifyAcc 累加多个签名的验证等式。对于s与ts在G1中的签名，验证等式为
+//
+//	e(t_i, r) == e(Y1_i, pk2) * e(m_i, g2)，e(s, r) == e(Y1_0, g2) * e(g1, pk2)
+//
+// 以随机数δ_i、δ_s合并后得到
+//
+//	e(Σδ_i*t_i + δ_s*s, r) * e(-Σδ_i*Y1_i - δ_s*g1, pk2) * e(-Σδ_i*m_i - δ_s*Y1_0, g2) == 1
+//
+// 其中与g2配对的项在所有签名之间共享，s与ts在G2中的签名与之对称
+type verifyAcc struct {
+	args   []*utils.EArg
+	withG2 *bn.G1 // 与g2配对的项
+	withG1 *bn.G2 // 与g1配对的项
+}
+
+func newVerifyAcc() *verifyAcc {
+	return &verifyAcc{
+		withG2: utils.NewG1(big.NewInt(0)),
+		withG1: utils.NewG2(big.NewInt(0)),
+	}
+}
+
+// add 将签名sig的验证等式累加到acc中，m与sig的长度需已经过检查。
+// 各消息等式的标量乘法由至多GOMAXPROCS个goroutine分段计算
+func (acc *verifyAcc) add(sp *Parameters, pk *PK, m *Message, sig *Signature) error {
+	n := len(sig.ts)
+	deltas := make([]*big.Int, n+1) // deltas[n]对应e(r, s)等式
+	for i := range deltas {
+		delta, err := randBatchCoeff()
+		if err != nil {
+			return err
+		}
+		deltas[i] = delta
+	}
+	y := func(i int) any {
+		if sig.STG1 {
+			return sp.Y1s[i]
+		}
+		return sp.Y2s[i]
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	parts := make([][3]any, workers)
+	errs := make([]error, workers)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			parts[w], errs[w] = sig.sumTerms(y, m, deltas, w*n/workers, (w+1)*n/workers)
+		}(w)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	// sum依次为与r、pk及另一个群的生成元配对的项
+	negS := utils.AddInv(deltas[n], bn.Order)
+	lhs, err := utils.ScalarMult(sig.s, deltas[n])
+	if err != nil {
+		return err
+	}
+	withGen, err := utils.ScalarMult(y(0), negS)
+	if err != nil {
+		return err
+	}
+	sum := [3]any{lhs, utils.ScalarBaseMult(sig.STG1, negS), withGen}
+	for _, part := range parts {
+		for j := range sum {
+			if sum[j], err = utils.Add(sum[j], part[j]); err != nil {
+				return err
+			}
+		}
+	}
+
+	acc.args = append(acc.args, utils.NewEArg(sum[0], sig.r, nil))
+	if sig.STG1 {
+		acc.args = append(acc.args, utils.NewEArg(sum[1], pk.pk2, nil))
+		acc.withG2.Add(acc.withG2, sum[2].(*bn.G1))
+	} else {
+		acc.args = append(acc.args, utils.NewEArg(pk.pk1, sum[1], nil))
+		acc.withG1.Add(acc.withG1, sum[2].(*bn.G2))
+	}
+
+	return nil
+}
+
+// sumTerms 计算下标在[lo, hi)内的消息等式对三个配对项的贡献：Σδ_i*t_i、-Σδ_i*Y_i与-Σδ_i*m_i
+func (sig *Signature) sumTerms(y func(int) any, m *Message, deltas []*big.Int, lo, hi int) ([3]any, error) {
+	zero := big.NewInt(0)
+	sum := [3]any{
+		utils.ScalarBaseMult(sig.STG1, zero),
+		utils.ScalarBaseMult(sig.STG1, zero),
+		utils.ScalarBaseMult(sig.STG1, zero),
+	}
+	for i := lo; i < hi; i++ {
+		neg := utils.AddInv(deltas[i], bn.Order)
+		terms := [3]struct {
+			elem any
+			k    *big.Int
+		}{
+			{sig.ts[i], deltas[i]},
+			{y(i), neg},
+			{m.ms[i], neg},
+		}
+		for j, term := range terms {
+			e, err := utils.ScalarMult(term.elem, term.k)
+			if err != nil {
+				return sum, err
+			}
+			if sum[j], err = utils.Add(sum[j], e); err != nil {
+				return sum, err
+			}
+		}
+	}
+
+	return sum, nil
+}
+
+// eArgs 返回acc中所有等式合并后的配对项
+func (acc *verifyAcc) eArgs() []*utils.EArg {
+	return append(acc.args,
+		utils.NewEArg(acc.withG2, utils.G2Generator(), nil),
+		utils.NewEArg(utils.G1Generator(), acc.withG1, nil),
+	)
+}
+
+// randBatchCoeff 返回batchCoeffBits比特的随机系数
+func randBatchCoeff() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), batchCoeffBits))
+}
@@ -0,0 +1,59 @@
+package groth_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/TomCN0803/taat-lib/pkg/groth"
+)
+
+func FuzzSignatureUnmarshal(f *testing.F) {
+	sp, err := groth.Setup(3, 3)
+	if err != nil {
+		f.Fatal(err)
+	}
+	sk, _ := groth.GenKeyPair(nil)
+	for _, m := range [][]any{randnG1s(2), randnG2s(3)} {
+		msg, err := groth.NewMessage(m)
+		if err != nil {
+			f.Fatal(err)
+		}
+		sig, err := groth.NewSignature(sp, sk, msg)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(sig.Marshal())
+	}
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, buff []byte) {
+		sig := new(groth.Signature)
+		if err := sig.Unmarshal(buff); err != nil {
+			return
+		}
+		if !bytes.Equal(sig.Marshal(), buff) {
+			t.Fatalf("re-marshaled result differs from input %x", buff)
+		}
+		if err := sig.Validate(sp); err != nil && !errors.Is(err, groth.ErrArgOverflow) {
+			t.Fatalf("unexpected validation error %v", err)
+		}
+	})
+}
+
+func FuzzParametersUnmarshal(f *testing.F) {
+	sp, err := groth.Setup(2, 1)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(sp.Marshal())
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, buff []byte) {
+		sp := new(groth.Parameters)
+		if err := sp.Unmarshal(buff); err != nil {
+			return
+		}
+		if !bytes.Equal(sp.Marshal(), buff) {
+			t.Fatalf("re-marshaled result differs from input %x", buff)
+		}
+	})
+}
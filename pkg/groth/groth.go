@@ -2,10 +2,10 @@ package groth
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/big"
-	"sync"
 
 	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 	bn "github.com/cloudflare/bn256"
@@ -17,6 +17,7 @@ var (
 	ErrInconsistentArgLen   = errors.New("inconsistent argument length")
 	ErrFailedERSPredicate   = errors.New("failed for e(r, s) predicate")
 	ErrFailedMsgPredicate   = errors.New("failed for message predicate")
+	ErrEmptyTs              = errors.New("signature must have at least one t")
 )
 
 // Parameters Groth公共参数
@@ -156,46 +157,35 @@ func NewSignature(sp *Parameters, sk *big.Int, m *Message) (*Signature, error) {
 	return sig, nil
 }
 
-// Verify 验证groth签名
+// Verify 验证groth签名。签名的len(m)+1个验证等式以随机系数合并为一个配对乘积（见 verifyAcc），
+// 只需一次multi-Miller loop与一次最终幂运算；合并后的等式不成立时再逐个检查各等式，
+// 以返回 ErrFailedMsgPredicate 或 ErrFailedERSPredicate
 func (sig *Signature) Verify(sp *Parameters, pk *PK, m *Message) error {
 	const prefix = "failed to verify groth signature"
-	ny1, ny2 := len(sp.Y1s), len(sp.Y2s)
-	if sig.STG1 && (len(m.ms) > ny1 || len(sig.ts) > ny1) {
-		return fmt.Errorf("%s: %w, at most %d", prefix, ErrArgOverflow, ny1)
+	if err := sig.checkArgs(sp, m); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
 	}
-	if !sig.STG1 && (len(m.ms) > ny2 || len(sig.ts) > ny2) {
-		return fmt.Errorf("%s: %w, at most %d", prefix, ErrArgOverflow, ny2)
+
+	acc := newVerifyAcc()
+	if err := acc.add(sp, pk, m, sig); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
 	}
-	if len(m.ms) != len(sig.ts) {
-		return fmt.Errorf(
-			"%s: %w, m(%d) must be equal to ts(%d)",
-			prefix,
-			ErrInconsistentArgLen,
-			len(m.ms),
-			len(sig.ts),
-		)
+	if utils.IsEProductOne(acc.eArgs()) {
+		return nil
 	}
 
-	var err error
-	o := sync.Once{}
-	wg := sync.WaitGroup{}
-	efn := func(i int, g1a, g1b, g1c *bn.G1, g2a, g2b, g2c *bn.G2) {
-		defer wg.Done()
-		elhs := bn.Pair(g1a, g2a)
-		erhs := new(bn.GT).Add(bn.Miller(g1b, g2b), bn.Miller(g1c, g2c)).Finalize()
-		if !utils.Equals(elhs, erhs) {
-			o.Do(func() {
-				if i < len(m.ms) {
-					err = fmt.Errorf("%s: %w, at index %d", prefix, ErrFailedMsgPredicate, i)
-				} else {
-					err = fmt.Errorf("%s: %w", prefix, ErrFailedERSPredicate)
-				}
-			})
-		}
+	if err := sig.verifyEach(sp, pk, m); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
 	}
 
-	g1 := new(bn.G1).ScalarBaseMult(big.NewInt(1))
-	g2 := new(bn.G2).ScalarBaseMult(big.NewInt(1))
+	return nil
+}
+
+// verifyEach 逐个检查签名的验证等式e(a1, a2) == e(b1, b2) * e(c1, c2)，返回第一个不成立的等式对应的错误
+func (sig *Signature) verifyEach(sp *Parameters, pk *PK, m *Message) error {
+	negOne := utils.AddInv(big.NewInt(1), bn.Order)
+	g1 := utils.G1Generator()
+	g2 := utils.G2Generator()
 	for i := 0; i < len(m.ms)+1; i++ {
 		var g1a, g1b, g1c *bn.G1
 		var g2a, g2b, g2c *bn.G2
@@ -217,13 +207,182 @@ func (sig *Signature) Verify(sp *Parameters, pk *PK, m *Message) error {
 			}
 		}
 
-		wg.Add(1)
-		go efn(i, g1a, g1b, g1c, g2a, g2b, g2c)
+		ok := utils.IsEProductOne([]*utils.EArg{
+			utils.NewEArg(g1a, g2a, nil),
+			utils.NewEArg(g1b, g2b, negOne),
+			utils.NewEArg(g1c, g2c, negOne),
+		})
+		if ok {
+			continue
+		}
+		if i < len(m.ms) {
+			return fmt.Errorf("%w, at index %d", ErrFailedMsgPredicate, i)
+		}
+		return ErrFailedERSPredicate
+	}
+
+	return nil
+}
+
+// Validate 检查sig是否适用于公共参数sp，即ts的数量在1与sp中同一个群的Y的数量之间，
+// 由 Signature.Unmarshal 或 ReadSignature 得到的签名在使用前应先调用 Validate
+func (sig *Signature) Validate(sp *Parameters) error {
+	if err := sig.checkTs(sp); err != nil {
+		return fmt.Errorf("invalid groth signature: %w", err)
+	}
+
+	return nil
+}
+
+// checkTs 检查ts的数量是否在1与sp中同一个群的Y的数量之间
+func (sig *Signature) checkTs(sp *Parameters) error {
+	if len(sig.ts) == 0 {
+		return ErrEmptyTs
+	}
+	ny := len(sp.Y2s)
+	if sig.STG1 {
+		ny = len(sp.Y1s)
+	}
+	if len(sig.ts) > ny {
+		return fmt.Errorf("%w, at most %d", ErrArgOverflow, ny)
+	}
+
+	return nil
+}
+
+// checkArgs 检查m与sig的长度是否与sp一致
+func (sig *Signature) checkArgs(sp *Parameters, m *Message) error {
+	if err := sig.checkTs(sp); err != nil {
+		return err
+	}
+	ny1, ny2 := len(sp.Y1s), len(sp.Y2s)
+	if sig.STG1 && len(m.ms) > ny1 {
+		return fmt.Errorf("%w, at most %d", ErrArgOverflow, ny1)
+	}
+	if !sig.STG1 && len(m.ms) > ny2 {
+		return fmt.Errorf("%w, at most %d", ErrArgOverflow, ny2)
+	}
+	if len(m.ms) != len(sig.ts) {
+		return fmt.Errorf("%w, m(%d) must be equal to ts(%d)", ErrInconsistentArgLen, len(m.ms), len(sig.ts))
+	}
+	if m.InG1 != sig.STG1 {
+		return fmt.Errorf("%w, message and signature are in different groups", ErrInconsistentMsgType)
+	}
+
+	return nil
+}
+
+// Marshal marshals sig as STG1 || r || s || len(ts) || ts, len(ts) is a 4-byte big endian integer.
+func (sig *Signature) Marshal() []byte {
+	res := utils.AppendBool(nil, sig.STG1)
+	res, _ = utils.AppendElem(res, sig.r)
+	res, _ = utils.AppendElem(res, sig.s)
+	res = binary.BigEndian.AppendUint32(res, uint32(len(sig.ts)))
+	for _, t := range sig.ts {
+		res, _ = utils.AppendElem(res, t)
+	}
+
+	return res
+}
+
+// Unmarshal reads from byte slice buff produced by Signature.Marshal and sets sig to the result,
+// the number of ts must be at least 1, whether it fits the parameters is checked by Signature.Validate.
+func (sig *Signature) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal groth signature"
+	d := utils.NewDecoder(buff)
+	res, err := ReadSignature(d)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	*sig = *res
+
+	return nil
+}
+
+// ReadSignature reads a Signature produced by Signature.Marshal from d,
+// it is used to decode a signature embedded in a larger encoding. As with Signature.Unmarshal,
+// the result should be checked against the parameters by Signature.Validate.
+func ReadSignature(d *utils.Decoder) (*Signature, error) {
+	stG1, err := d.ReadBool()
+	if err != nil {
+		return nil, err
+	}
+	sig := &Signature{STG1: stG1}
+	if sig.r, err = d.ReadElem(!stG1); err != nil {
+		return nil, err
+	}
+	if sig.s, err = d.ReadElem(stG1); err != nil {
+		return nil, err
+	}
+	nts, err := d.ReadCount(1)
+	if err != nil {
+		return nil, err
+	}
+	if nts == 0 {
+		return nil, ErrEmptyTs
+	}
+	sig.ts = make([]any, nts)
+	for i := range sig.ts {
+		if sig.ts[i], err = d.ReadElem(stG1); err != nil {
+			return nil, err
+		}
+	}
+
+	return sig, nil
+}
+
+// Marshal marshals sp as len(Y1s) || Y1s || len(Y2s) || Y2s, lengths are 4-byte big endian integers.
+func (sp *Parameters) Marshal() []byte {
+	res := make([]byte, 0, 8+len(sp.Y1s)*utils.G1SizeByte+len(sp.Y2s)*utils.G2SizeByte)
+	res = binary.BigEndian.AppendUint32(res, uint32(len(sp.Y1s)))
+	for _, y := range sp.Y1s {
+		res = append(res, new(bn.G1).Set(y).Marshal()...)
+	}
+	res = binary.BigEndian.AppendUint32(res, uint32(len(sp.Y2s)))
+	for _, y := range sp.Y2s {
+		res = append(res, new(bn.G2).Set(y).Marshal()...)
 	}
 
-	wg.Wait()
+	return res
+}
+
+// Unmarshal reads from byte slice buff produced by Parameters.Marshal and sets sp to the result,
+// both Y1s and Y2s must be non-empty.
+func (sp *Parameters) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal groth parameters"
+	d := utils.NewDecoder(buff)
+	n1, err := d.ReadCount(utils.G1SizeByte)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	y1s := make([]*bn.G1, n1)
+	for i := range y1s {
+		if y1s[i], err = d.ReadG1(); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	n2, err := d.ReadCount(1)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	y2s := make([]*bn.G2, n2)
+	for i := range y2s {
+		if y2s[i], err = d.ReadG2(); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if n1 == 0 || n2 == 0 {
+		return fmt.Errorf("%s: %w", prefix, ErrIllegalMaxMessageNum)
+	}
+	sp.Y1s, sp.Y2s = y1s, y2s
 
-	return err
+	return nil
 }
 
 // Randomize randomizes sig with rho if rho is provided or a random big int.
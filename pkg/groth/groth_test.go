@@ -2,9 +2,11 @@ package groth_test
 
 import (
 	"crypto/rand"
+	"math/big"
 	"testing"
 
 	"github.com/TomCN0803/taat-lib/pkg/groth"
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 	bn "github.com/cloudflare/bn256"
 	"github.com/stretchr/testify/require"
 )
@@ -58,6 +60,512 @@ func TestGroth(t *testing.T) {
 
 }
 
+func TestVerifyDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	sp, err := groth.Setup(10, 10)
+	require.NoError(t, err)
+	sk, pk := groth.GenKeyPair(nil)
+	testCases := []struct {
+		name   string
+		inG1   bool
+		tamper func(m []any, sig []byte)
+		err    error
+		errMsg string
+	}{
+		{
+			"tampered message in G1",
+			true,
+			func(m []any, sig []byte) {
+				m[3] = utils.G1Generator()
+			},
+			groth.ErrFailedMsgPredicate,
+			"at index 3",
+		},
+		{
+			"tampered message in G2",
+			false,
+			func(m []any, sig []byte) {
+				m[7] = utils.G2Generator()
+			},
+			groth.ErrFailedMsgPredicate,
+			"at index 7",
+		},
+		{
+			"tampered s in G1",
+			true,
+			func(m []any, sig []byte) {
+				// STG1 || r(G2) || s(G1) || ...
+				copy(sig[1+utils.G2SizeByte:], utils.G1Generator().Marshal())
+			},
+			groth.ErrFailedERSPredicate,
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			m := randnG2s(10)
+			if tc.inG1 {
+				m = randnG1s(10)
+			}
+			msg, err := groth.NewMessage(m)
+			require.NoError(t, err)
+			sig, err := groth.NewSignature(sp, sk, msg)
+			require.NoError(t, err)
+			require.NoError(t, sig.Verify(sp, pk, msg))
+
+			buff := sig.Marshal()
+			tc.tamper(m, buff)
+			msg, err = groth.NewMessage(m)
+			require.NoError(t, err)
+			require.NoError(t, sig.Unmarshal(buff))
+			err = sig.Verify(sp, pk, msg)
+			require.ErrorIs(t, err, tc.err)
+			require.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	t.Parallel()
+
+	sp, pks, ms, sigs := mockBatch(t, 6, 10)
+	_, otherPK := groth.GenKeyPair(nil)
+	testCases := []struct {
+		name   string
+		mutate func(pks []*groth.PK, ms []*groth.Message, sigs []*groth.Signature)
+		failed []int
+		err    error
+	}{
+		{
+			"all valid",
+			func(pks []*groth.PK, ms []*groth.Message, sigs []*groth.Signature) {},
+			nil,
+			nil,
+		},
+		{
+			"swapped messages",
+			func(pks []*groth.PK, ms []*groth.Message, sigs []*groth.Signature) {
+				ms[0], ms[2] = ms[2], ms[0]
+			},
+			[]int{0, 2},
+			groth.ErrFailedMsgPredicate,
+		},
+		{
+			"wrong public key in G2",
+			func(pks []*groth.PK, ms []*groth.Message, sigs []*groth.Signature) {
+				pks[3] = otherPK
+			},
+			[]int{3},
+			groth.ErrFailedMsgPredicate,
+		},
+		{
+			"message in another group",
+			func(pks []*groth.PK, ms []*groth.Message, sigs []*groth.Signature) {
+				ms[0] = ms[1]
+			},
+			nil,
+			groth.ErrInconsistentMsgType,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			pks := append([]*groth.PK{}, pks...)
+			ms := append([]*groth.Message{}, ms...)
+			tc.mutate(pks, ms, sigs)
+			err := groth.BatchVerify(sp, pks, ms, sigs)
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.err)
+			if tc.failed != nil {
+				var be *groth.BatchError
+				require.ErrorAs(t, err, &be)
+				require.ErrorIs(t, err, groth.ErrBatchVerifyFailed)
+				require.Equal(t, tc.failed, be.Failed)
+			}
+		})
+	}
+
+	err := groth.BatchVerify(sp, pks[1:], ms, sigs)
+	require.ErrorIs(t, err, groth.ErrInconsistentArgLen)
+}
+
+func BenchmarkVerify(b *testing.B) {
+	sp, pks, ms, sigs := mockBatch(b, 16, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, sig := range sigs {
+			_ = sig.Verify(sp, pks[j], ms[j])
+		}
+	}
+}
+
+func BenchmarkBatchVerify(b *testing.B) {
+	sp, pks, ms, sigs := mockBatch(b, 16, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = groth.BatchVerify(sp, pks, ms, sigs)
+	}
+}
+
+// mockBatch 生成n个不同私钥对长度为size的消息的签名，偶数下标的签名在G1中，奇数下标的在G2中
+func mockBatch(tb testing.TB, n, size int) (*groth.Parameters, []*groth.PK, []*groth.Message, []*groth.Signature) {
+	sp, err := groth.Setup(size, size)
+	require.NoError(tb, err)
+	pks := make([]*groth.PK, n)
+	ms := make([]*groth.Message, n)
+	sigs := make([]*groth.Signature, n)
+	for i := range sigs {
+		var sk *big.Int
+		sk, pks[i] = groth.GenKeyPair(nil)
+		m := randnG1s(size)
+		if i%2 == 1 {
+			m = randnG2s(size)
+		}
+		ms[i], err = groth.NewMessage(m)
+		require.NoError(tb, err)
+		sigs[i], err = groth.NewSignature(sp, sk, ms[i])
+		require.NoError(tb, err)
+	}
+
+	return sp, pks, ms, sigs
+}
+
+func TestSignatureMarshal(t *testing.T) {
+	t.Parallel()
+
+	sp, err := groth.Setup(3, 4)
+	require.NoError(t, err)
+	sk, pk := groth.GenKeyPair(nil)
+	testCases := []struct {
+		name string
+		inG1 bool
+	}{
+		{
+			"signature in G1",
+			true,
+		},
+		{
+			"signature in G2",
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var m []any
+			if tc.inG1 {
+				m = randnG1s(3)
+			} else {
+				m = randnG2s(4)
+			}
+			msg, err := groth.NewMessage(m)
+			require.NoError(t, err)
+			sig, err := groth.NewSignature(sp, sk, msg)
+			require.NoError(t, err)
+			buff := sig.Marshal()
+
+			sig2 := new(groth.Signature)
+			require.NoError(t, sig2.Unmarshal(buff))
+			require.Equal(t, tc.inG1, sig2.STG1)
+			require.Equal(t, buff, sig2.Marshal())
+			require.NoError(t, sig2.Validate(sp))
+			require.NoError(t, sig2.Verify(sp, pk, msg))
+
+			// 签名的ts数量超过另一组参数的容量
+			small, err := groth.Setup(2, 2)
+			require.NoError(t, err)
+			require.ErrorIs(t, sig2.Validate(small), groth.ErrArgOverflow)
+			require.ErrorIs(t, sig2.Verify(small, pk, msg), groth.ErrArgOverflow)
+			require.ErrorIs(t, new(groth.Signature).Unmarshal(buff[:len(buff)-1]), utils.ErrShortBuffer)
+			require.ErrorIs(t, new(groth.Signature).Unmarshal(append(buff, 0)), utils.ErrTrailingBytes)
+		})
+	}
+}
+
+func TestParametersMarshal(t *testing.T) {
+	t.Parallel()
+
+	sp, err := groth.Setup(3, 5)
+	require.NoError(t, err)
+	buff := sp.Marshal()
+
+	sp2 := new(groth.Parameters)
+	require.NoError(t, sp2.Unmarshal(buff))
+	require.Len(t, sp2.Y1s, 3)
+	require.Len(t, sp2.Y2s, 5)
+	require.Equal(t, buff, sp2.Marshal())
+
+	// 使用反序列化得到的参数签名与验证
+	sk, pk := groth.GenKeyPair(nil)
+	msg, err := groth.NewMessage(randnG2s(5))
+	require.NoError(t, err)
+	sig, err := groth.NewSignature(sp2, sk, msg)
+	require.NoError(t, err)
+	require.NoError(t, sig.Verify(sp, pk, msg))
+
+	empty := (&groth.Parameters{Y1s: sp.Y1s}).Marshal()
+	require.ErrorIs(t, new(groth.Parameters).Unmarshal(empty), groth.ErrIllegalMaxMessageNum)
+	require.ErrorIs(t, new(groth.Parameters).Unmarshal(buff[:len(buff)-1]), utils.ErrShortBuffer)
+	require.ErrorIs(t, new(groth.Parameters).Unmarshal(append(buff, 0)), utils.ErrTrailingBytes)
+}
+
+func TestSetupFromSeed(t *testing.T) {
+	t.Parallel()
+
+	seed := []byte("taat-lib groth setup test seed")
+	sp, err := groth.SetupFromSeed(seed, 4, 6)
+	require.NoError(t, err)
+	sp2, err := groth.SetupFromSeed(seed, 4, 6)
+	require.NoError(t, err)
+	require.Equal(t, sp.Marshal(), sp2.Marshal())
+	require.NoError(t, groth.VerifySetup(sp, seed))
+	for _, y := range sp.Y2s {
+		// 余因子已被清除，Y2s中的元素均位于阶为n的子群中
+		require.True(t, utils.IsInfinity(new(bn.G2).ScalarMult(y, bn.Order)))
+	}
+
+	// 使用由seed生成的参数签名与验证
+	sk, pk := groth.GenKeyPair(nil)
+	for _, m := range [][]any{randnG1s(4), randnG2s(6)} {
+		msg, err := groth.NewMessage(m)
+		require.NoError(t, err)
+		sig, err := groth.NewSignature(sp, sk, msg)
+		require.NoError(t, err)
+		require.NoError(t, sig.Verify(sp, pk, msg))
+	}
+
+	random, err := groth.Setup(4, 6)
+	require.NoError(t, err)
+	testCases := []struct {
+		name   string
+		sp     *groth.Parameters
+		seed   []byte
+		errMsg string
+	}{
+		{
+			"different seed",
+			sp,
+			[]byte("another seed"),
+			"Y1s[0] mismatch",
+		},
+		{
+			"random parameters",
+			random,
+			seed,
+			"Y1s[0] mismatch",
+		},
+		{
+			"tampered Y2",
+			&groth.Parameters{Y1s: sp.Y1s, Y2s: append(append([]*bn.G2{}, sp.Y2s[:5]...), random.Y2s[5])},
+			seed,
+			"Y2s[5] mismatch",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := groth.VerifySetup(tc.sp, tc.seed)
+			require.ErrorIs(t, err, groth.ErrParamsNotFromSeed)
+			require.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+
+	_, err = groth.SetupFromSeed(seed, 0, 1)
+	require.ErrorIs(t, err, groth.ErrIllegalMaxMessageNum)
+}
+
+func TestThresholdSignature(t *testing.T) {
+	t.Parallel()
+
+	const th, n = 2, 5
+	sp, err := groth.Setup(msize, msize)
+	require.NoError(t, err)
+	pk, kss, err := groth.GenThresholdKey(nil, th, n)
+	require.NoError(t, err)
+	require.Len(t, kss, n)
+
+	testCases := []struct {
+		name string
+		inG1 bool
+		ids  []uint64
+	}{
+		{
+			"2t-1 signers in G1",
+			true,
+			[]uint64{2, 4, 5},
+		},
+		{
+			"more than 2t-1 signers in G2",
+			false,
+			[]uint64{1, 2, 3, 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			m := randnG2s(msize)
+			if tc.inG1 {
+				m = randnG1s(msize)
+			}
+			msg, err := groth.NewMessage(m)
+			require.NoError(t, err)
+			psigs := runThresholdSign(t, sp, kss, th, tc.ids, msg)
+			// 签名者多于2t-1个时，任意2t-1个部分签名都可以合并
+			sig, err := groth.CombinePartialSignatures(sp, pk, msg, psigs[:2*th-1], th)
+			require.NoError(t, err)
+			require.NoError(t, sig.Verify(sp, pk, msg))
+			sig, err = groth.CombinePartialSignatures(sp, pk, msg, psigs[len(psigs)-2*th+1:], th)
+			require.NoError(t, err)
+			require.NoError(t, sig.Verify(sp, pk, msg))
+
+			_, err = groth.CombinePartialSignatures(sp, pk, msg, psigs[:2*th-2], th)
+			require.ErrorIs(t, err, groth.ErrWrongPartialSigNum)
+			_, err = groth.CombinePartialSignatures(sp, pk, msg, append(psigs, psigs[0]), th)
+			require.ErrorIs(t, err, groth.ErrWrongPartialSigNum)
+			_, err = groth.CombinePartialSignatures(sp, pk, msg, append(psigs[:1:1], psigs[:2*th-2]...), th)
+			require.ErrorIs(t, err, groth.ErrDuplicatePartialSig)
+			// 来自另一次签名的部分签名
+			other := runThresholdSign(t, sp, kss, th, tc.ids, msg)
+			_, err = groth.CombinePartialSignatures(sp, pk, msg, append(psigs[:2*th-2:2*th-2], other[2*th-2]), th)
+			require.ErrorIs(t, err, groth.ErrInvalidPartialSig)
+			_, err = groth.CombinePartialSignatures(sp, pk, msg, append(psigs[:2*th-2:2*th-2], nil), th)
+			require.ErrorIs(t, err, groth.ErrInvalidPartialSig)
+			_, err = groth.CombinePartialSignatures(sp, nil, msg, psigs[:2*th-1], th)
+			require.ErrorIs(t, err, groth.ErrInvalidPartialSig)
+			_, err = groth.CombinePartialSignatures(sp, pk, nil, psigs[:2*th-1], th)
+			require.ErrorIs(t, err, groth.ErrInvalidPartialSig)
+		})
+	}
+
+	msg, err := groth.NewMessage(randnG1s(msize))
+	require.NoError(t, err)
+	_, err = groth.NewThresholdSigner(sp, kss[0], th, []uint64{1, 2}, msg)
+	require.ErrorIs(t, err, groth.ErrNotEnoughSigners)
+	_, err = groth.NewThresholdSigner(sp, kss[0], th, []uint64{2, 3, 4}, msg)
+	require.ErrorIs(t, err, groth.ErrIllegalSignerSet)
+	_, err = groth.NewThresholdSigner(sp, nil, th, []uint64{1, 2, 3}, msg)
+	require.ErrorIs(t, err, groth.ErrIllegalSignerSet)
+	_, err = groth.NewThresholdSigner(nil, kss[0], th, []uint64{1, 2, 3}, msg)
+	require.ErrorIs(t, err, groth.ErrIllegalSignerSet)
+	_, err = groth.NewThresholdSigner(sp, kss[0], th, []uint64{1, 2, 3}, nil)
+	require.ErrorIs(t, err, groth.ErrIllegalSignerSet)
+	signer, err := groth.NewThresholdSigner(sp, kss[0], th, []uint64{1, 2, 3}, msg)
+	require.NoError(t, err)
+	_, err = signer.Round2(nil)
+	require.ErrorIs(t, err, groth.ErrWrongSignRound)
+	out, err := signer.Round1()
+	require.NoError(t, err)
+	_, err = signer.Round2([]*groth.SignShareMsg{out[1], out[1], out[1]})
+	require.ErrorIs(t, err, groth.ErrUnexpectedSignShare)
+	_, err = signer.Round2([]*groth.SignShareMsg{out[1], nil, out[1]})
+	require.ErrorIs(t, err, groth.ErrUnexpectedSignShare)
+
+	_, _, err = groth.GenThresholdKey(nil, n+1, n)
+	require.ErrorIs(t, err, groth.ErrIllegalThreshold)
+	// n >= t但不足2t-1时，得到的份额永远无法签名
+	_, _, err = groth.GenThresholdKey(nil, 3, 3)
+	require.ErrorIs(t, err, groth.ErrNotEnoughSigners)
+	_, _, err = groth.GenThresholdKey(nil, 2, 2)
+	require.ErrorIs(t, err, groth.ErrNotEnoughSigners)
+	_, _, err = groth.GenThresholdKey(nil, 1, 1)
+	require.NoError(t, err)
+}
+
+func TestThresholdMarshalMalformed(t *testing.T) {
+	t.Parallel()
+
+	const th, n = 2, 3
+	sp, err := groth.Setup(msize, msize)
+	require.NoError(t, err)
+	_, kss, err := groth.GenThresholdKey(nil, th, n)
+	require.NoError(t, err)
+	msg, err := groth.NewMessage(randnG2s(msize))
+	require.NoError(t, err)
+	signer, err := groth.NewThresholdSigner(sp, kss[0], th, []uint64{1, 2, 3}, msg)
+	require.NoError(t, err)
+	out, err := signer.Round1()
+	require.NoError(t, err)
+	psigs := runThresholdSign(t, sp, kss, th, []uint64{1, 2, 3}, msg)
+
+	testCases := []struct {
+		name string
+		v    interface{ Unmarshal([]byte) error }
+		buff []byte
+	}{
+		{"key share", new(groth.KeyShare), kss[0].Marshal()},
+		{"sign share message", new(groth.SignShareMsg), out[2].Marshal()},
+		{"partial signature", new(groth.PartialSignature), psigs[0].Marshal()},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.NoError(t, tc.v.Unmarshal(tc.buff))
+			require.ErrorIs(t, tc.v.Unmarshal(tc.buff[:len(tc.buff)-1]), utils.ErrShortBuffer)
+			require.ErrorIs(t, tc.v.Unmarshal(append(tc.buff, 0)), utils.ErrTrailingBytes)
+			require.ErrorIs(t, tc.v.Unmarshal(append([]byte{2}, tc.buff[1:]...)), groth.ErrUnknownThresholdVer)
+			zeroID := append([]byte{}, tc.buff...)
+			copy(zeroID[1:9], make([]byte, 8))
+			require.ErrorIs(t, tc.v.Unmarshal(zeroID), groth.ErrZeroSignerID)
+		})
+	}
+}
+
+// runThresholdSign 由ids中的签名者执行门限签名的两轮，返回各签名者的部分签名。
+// 份额、第一轮消息与部分签名均经过序列化与反序列化，模拟签名者分布在不同进程中
+func runThresholdSign(
+	t *testing.T,
+	sp *groth.Parameters,
+	kss []*groth.KeyShare,
+	th uint64,
+	ids []uint64,
+	msg *groth.Message,
+) []*groth.PartialSignature {
+	signers := make([]*groth.ThresholdSigner, len(ids))
+	inbox := make(map[uint64][]*groth.SignShareMsg, len(ids))
+	for i, id := range ids {
+		ks := new(groth.KeyShare)
+		require.NoError(t, ks.Unmarshal(kss[id-1].Marshal()))
+		require.Equal(t, id, ks.ID())
+		var err error
+		signers[i], err = groth.NewThresholdSigner(sp, ks, th, ids, msg)
+		require.NoError(t, err)
+		out, err := signers[i].Round1()
+		require.NoError(t, err)
+		for to, m := range out {
+			recv := new(groth.SignShareMsg)
+			require.NoError(t, recv.Unmarshal(m.Marshal()))
+			inbox[to] = append(inbox[to], recv)
+		}
+	}
+	psigs := make([]*groth.PartialSignature, len(ids))
+	for i, id := range ids {
+		ps, err := signers[i].Round2(inbox[id])
+		require.NoError(t, err)
+		psigs[i] = new(groth.PartialSignature)
+		require.NoError(t, psigs[i].Unmarshal(ps.Marshal()))
+		require.Equal(t, ps.Marshal(), psigs[i].Marshal())
+	}
+
+	return psigs
+}
+
 func randnG1s(n int) []any {
 	res := make([]any, n)
 	for i := range res {
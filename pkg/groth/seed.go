@@ -0,0 +1,73 @@
+package groth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+)
+
+var ErrParamsNotFromSeed = errors.New("parameters are not derived from the seed")
+
+// 派生Y1s与Y2s时使用的域分离标签
+var (
+	seedDSTY1 = []byte("TAAT-LIB-GROTH-SETUP-Y1")
+	seedDSTY2 = []byte("TAAT-LIB-GROTH-SETUP-Y2")
+)
+
+// SetupFromSeed 由公开的seed确定性地生成Groth公共参数，Y1s[i]与Y2s[i]分别由seed || i哈希到G1与G2得到，
+// 因此任何人都无法得知它们的离散对数，且相同的seed总是得到相同的参数，见 VerifySetup
+func SetupFromSeed(seed []byte, max1, max2 int) (*Parameters, error) {
+	if max1 <= 0 || max2 <= 0 {
+		return nil, fmt.Errorf("failed to set up groth from seed: %w", ErrIllegalMaxMessageNum)
+	}
+	y1s := make([]*bn.G1, max1)
+	for i := range y1s {
+		y1, err := utils.HashToG1(seedIndex(seed, i), seedDSTY1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up groth from seed: %w", err)
+		}
+		y1s[i] = y1
+	}
+	y2s := make([]*bn.G2, max2)
+	for i := range y2s {
+		y2, err := utils.HashToG2(seedIndex(seed, i), seedDSTY2)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up groth from seed: %w", err)
+		}
+		y2s[i] = y2
+	}
+
+	return &Parameters{y1s, y2s}, nil
+}
+
+// VerifySetup 检查已公布的参数sp是否由seed经 SetupFromSeed 生成
+func VerifySetup(sp *Parameters, seed []byte) error {
+	const prefix = "failed to verify groth setup"
+	exp, err := SetupFromSeed(seed, len(sp.Y1s), len(sp.Y2s))
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	for i, y := range sp.Y1s {
+		if !bytes.Equal(y.Marshal(), exp.Y1s[i].Marshal()) {
+			return fmt.Errorf("%s: %w, Y1s[%d] mismatch", prefix, ErrParamsNotFromSeed, i)
+		}
+	}
+	for i, y := range sp.Y2s {
+		if !bytes.Equal(y.Marshal(), exp.Y2s[i].Marshal()) {
+			return fmt.Errorf("%s: %w, Y2s[%d] mismatch", prefix, ErrParamsNotFromSeed, i)
+		}
+	}
+
+	return nil
+}
+
+// seedIndex 返回seed || i，i为4字节大端整数
+func seedIndex(seed []byte, i int) []byte {
+	msg := make([]byte, 0, len(seed)+4)
+	msg = append(msg, seed...)
+	return binary.BigEndian.AppendUint32(msg, uint32(i))
+}
@@ -0,0 +1,507 @@
+package groth
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	"github.com/TomCN0803/taat-lib/pkg/shamir"
+	bn "github.com/cloudflare/bn256"
+)
+
+var (
+	ErrIllegalThreshold    = errors.New("illegal threshold, must satisfy 0 < t <= n")
+	ErrNotEnoughSigners    = errors.New("not enough signers, need at least 2t-1")
+	ErrIllegalSignerSet    = errors.New("illegal signer set")
+	ErrWrongSignRound      = errors.New("wrong threshold signing round")
+	ErrUnexpectedSignShare = errors.New("unexpected sign share message")
+	ErrDuplicatePartialSig = errors.New("duplicate partial signature")
+	ErrWrongPartialSigNum  = errors.New("wrong number of partial signatures, need exactly 2t-1")
+	ErrInvalidPartialSig   = errors.New("invalid partial signature")
+	ErrUnknownThresholdVer = errors.New("unknown threshold signing encoding version")
+	ErrZeroSignerID        = errors.New("illegal signer id, must be greater than 0")
+)
+
+// thresholdEncodingV1 KeyShare、SignShareMsg 与 PartialSignature 序列化格式的版本号
+const thresholdEncodingV1 byte = 1
+
+// KeyShare 门限签名者持有的Groth私钥份额sk_j
+type KeyShare struct {
+	id uint64
+	sk *big.Int
+}
+
+// ID returns the signer id of ks.
+func (ks *KeyShare) ID() uint64 {
+	return ks.id
+}
+
+// GenThresholdKey 以门限t将Groth私钥分享给id为1...n的签名者，如果提供了私钥即isk不为空，则分享isk，否则随机生成私钥。
+// t为保密门限，任意t-1个份额都不会泄露私钥；但 ThresholdSigner 计算乘积份额时多项式次数翻倍，
+// 每次签名需要至少2t-1个签名者在线，因此要求n >= 2t-1，否则返回 ErrNotEnoughSigners
+func GenThresholdKey(isk *big.Int, t, n uint64) (*PK, []*KeyShare, error) {
+	const prefix = "failed to generate groth threshold key"
+	if t == 0 || t > n {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, ErrIllegalThreshold)
+	}
+	if n < 2*t-1 {
+		return nil, nil, fmt.Errorf("%s: %w, got n = %d", prefix, ErrNotEnoughSigners, n)
+	}
+	sk, pk := GenKeyPair(isk)
+	shares := shamir.GenShares(shamir.GenRandPoly(t, sk, bn.Order), n, bn.Order)
+	kss := make([]*KeyShare, n)
+	for i, share := range shares {
+		kss[i] = &KeyShare{share.X().Uint64(), share.Y()}
+	}
+
+	return pk, kss, nil
+}
+
+// SignShareMsg 门限签名第一轮中签名者From发送给签名者To的份额：
+// u、a为两个t-1次随机多项式在To处的值，zs为len(m)+2个常数项为0的2t-2次多项式在To处的值。
+// 获得t个签名者的份额即可恢复本次签名的u与a，进而由部分签名得到私钥份额，
+// 因此 SignShareMsg.Marshal 的结果只能通过机密且认证的信道发送给To
+type SignShareMsg struct {
+	From, To uint64
+	u, a     *big.Int
+	zs       []*big.Int
+}
+
+// PartialSignature 签名者在第二轮产生的部分签名。记u、a为所有签名者共同分享的随机数，ρ = 1/u，
+// 则mu、ra、s与ts分别是u*a、g^a、(Y_0 * g^sk)^u与(m_i * Y_i^sk)^u的份额，见 CombinePartialSignatures
+type PartialSignature struct {
+	id   uint64
+	inG1 bool
+	mu   *big.Int
+	ra   any
+	s    any
+	ts   []any
+}
+
+// ID returns the signer id of ps.
+func (ps *PartialSignature) ID() uint64 {
+	return ps.id
+}
+
+// ThresholdSigner 门限签名中的一个签名者，对消息m的签名分为两轮：
+//  1. Round1 为本次签名生成随机多项式，返回发送给ids中每个签名者（包括自身）的 SignShareMsg
+//  2. Round2 收到ids中所有签名者发来的 SignShareMsg 后，产生发送给合并者的 PartialSignature
+//
+// u*sk与u*a的份额位于2t-2次多项式上，因此ids中至少需要2t-1个签名者。
+// 协议只在诚实多数下安全且不能识别出错的签名者：部分签名无法单独验证，
+// 任一部分签名错误时 CombinePartialSignatures 只能返回 ErrInvalidPartialSig
+type ThresholdSigner struct {
+	sp    *Parameters
+	ks    *KeyShare
+	t     uint64
+	ids   []uint64
+	m     *Message
+	round int
+}
+
+// NewThresholdSigner 创建持有份额ks的签名者，与ids中的签名者以门限t共同对m签名
+func NewThresholdSigner(sp *Parameters, ks *KeyShare, t uint64, ids []uint64, m *Message) (*ThresholdSigner, error) {
+	const prefix = "failed to create groth threshold signer"
+	if sp == nil || ks == nil || m == nil {
+		return nil, fmt.Errorf("%s: %w, nil parameters, key share or message", prefix, ErrIllegalSignerSet)
+	}
+	if t == 0 {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrIllegalThreshold)
+	}
+	if uint64(len(ids)) < 2*t-1 {
+		return nil, fmt.Errorf("%s: %w, got %d", prefix, ErrNotEnoughSigners, len(ids))
+	}
+	seen := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			return nil, fmt.Errorf("%s: %w, zero or duplicate id %d", prefix, ErrIllegalSignerSet, id)
+		}
+		seen[id] = true
+	}
+	if !seen[ks.id] {
+		return nil, fmt.Errorf("%s: %w, signer %d not in the set", prefix, ErrIllegalSignerSet, ks.id)
+	}
+	ny := len(sp.Y2s)
+	if m.InG1 {
+		ny = len(sp.Y1s)
+	}
+	if m.Len() > ny {
+		return nil, fmt.Errorf("%s: %w, message length at most %d, got %d instead", prefix, ErrArgOverflow, ny, m.Len())
+	}
+
+	return &ThresholdSigner{
+		sp:  sp,
+		ks:  ks,
+		t:   t,
+		ids: append([]uint64{}, ids...),
+		m:   m,
+	}, nil
+}
+
+// Round1 生成u、a以及用于掩盖乘积份额的零多项式，返回发送给各签名者的份额，键为接收者的id
+func (s *ThresholdSigner) Round1() (map[uint64]*SignShareMsg, error) {
+	const prefix = "failed to run groth threshold signing round 1"
+	if s.round != 0 {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrWrongSignRound)
+	}
+	u, err := rand.Int(rand.Reader, bn.Order)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	a, err := rand.Int(rand.Reader, bn.Order)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	polyU := shamir.GenRandPoly(s.t, u, bn.Order)
+	polyA := shamir.GenRandPoly(s.t, a, bn.Order)
+	polyZs := make([][]*big.Int, s.m.Len()+2)
+	for i := range polyZs {
+		polyZs[i] = shamir.GenRandPoly(2*s.t-1, big.NewInt(0), bn.Order)
+	}
+
+	msgs := make(map[uint64]*SignShareMsg, len(s.ids))
+	for _, id := range s.ids {
+		x := new(big.Int).SetUint64(id)
+		msg := &SignShareMsg{
+			From: s.ks.id,
+			To:   id,
+			u:    shamir.EvalPoly(polyU, x, bn.Order),
+			a:    shamir.EvalPoly(polyA, x, bn.Order),
+			zs:   make([]*big.Int, len(polyZs)),
+		}
+		for i, poly := range polyZs {
+			msg.zs[i] = shamir.EvalPoly(poly, x, bn.Order)
+		}
+		msgs[id] = msg
+	}
+	s.round = 1
+
+	return msgs, nil
+}
+
+// Round2 汇总ids中每个签名者发来的份额，计算并返回部分签名
+func (s *ThresholdSigner) Round2(msgs []*SignShareMsg) (*PartialSignature, error) {
+	const prefix = "failed to run groth threshold signing round 2"
+	if s.round != 1 {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrWrongSignRound)
+	}
+	if len(msgs) != len(s.ids) {
+		return nil, fmt.Errorf("%s: %w, expected %d, got %d", prefix, ErrUnexpectedSignShare, len(s.ids), len(msgs))
+	}
+
+	u, a := big.NewInt(0), big.NewInt(0)
+	zs := make([]*big.Int, s.m.Len()+2)
+	for i := range zs {
+		zs[i] = big.NewInt(0)
+	}
+	from := make(map[uint64]bool, len(msgs))
+	for _, msg := range msgs {
+		if msg == nil {
+			return nil, fmt.Errorf("%s: %w, nil share", prefix, ErrUnexpectedSignShare)
+		}
+		if msg.To != s.ks.id || from[msg.From] || !containsSigner(s.ids, msg.From) || len(msg.zs) != len(zs) {
+			return nil, fmt.Errorf("%s: %w from %d", prefix, ErrUnexpectedSignShare, msg.From)
+		}
+		from[msg.From] = true
+		u = utils.AddMod(u, msg.u)
+		a = utils.AddMod(a, msg.a)
+		for i := range zs {
+			zs[i] = utils.AddMod(zs[i], msg.zs[i])
+		}
+	}
+
+	usk := utils.MulMod(u, s.ks.sk)
+	ps := &PartialSignature{
+		id:   s.ks.id,
+		inG1: s.m.InG1,
+		mu:   utils.AddMod(utils.MulMod(u, a), zs[0]),
+		ts:   make([]any, s.m.Len()),
+	}
+	// s = Y_0^u * g^(u*sk + z_1)，ts[i] = m_i^u * Y_i^(u*sk + z_(i+2))
+	if s.m.InG1 {
+		ps.ra = utils.NewG2(a)
+		ps.s = utils.ProductOfExpG1(s.sp.Y1s[0], u, utils.G1Generator(), utils.AddMod(usk, zs[1]))
+		for i := range ps.ts {
+			ps.ts[i] = utils.ProductOfExpG1(s.m.ms[i].(*bn.G1), u, s.sp.Y1s[i], utils.AddMod(usk, zs[i+2]))
+		}
+	} else {
+		ps.ra = utils.NewG1(a)
+		ps.s = utils.ProductOfExpG2(s.sp.Y2s[0], u, utils.G2Generator(), utils.AddMod(usk, zs[1]))
+		for i := range ps.ts {
+			ps.ts[i] = utils.ProductOfExpG2(s.m.ms[i].(*bn.G2), u, s.sp.Y2s[i], utils.AddMod(usk, zs[i+2]))
+		}
+	}
+	s.round = 2
+
+	return ps, nil
+}
+
+// CombinePartialSignatures 使用psigs中恰好2t-1个部分签名合并出pk对m的Groth签名，签名者多于2t-1个时由调用者选择。
+// 在指数上进行Lagrange插值得到μ = u*a、g^a、s = (Y_0 * g^sk)^u与t_i = (m_i * Y_i^sk)^u，
+// 再令r = (g^a)^(1/μ) = g^(1/u)，即得到ρ = 1/u的签名，可直接使用 Signature.Verify 验证。
+// 合并后的签名无效时返回 ErrInvalidPartialSig，此时无法得知哪个部分签名有误，只能重新发起签名
+func CombinePartialSignatures(sp *Parameters, pk *PK, m *Message, psigs []*PartialSignature, t uint64) (*Signature, error) {
+	const prefix = "failed to combine groth partial signatures"
+	if sp == nil || pk == nil || m == nil {
+		return nil, fmt.Errorf("%s: %w, nil parameters, public key or message", prefix, ErrInvalidPartialSig)
+	}
+	if t == 0 {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrIllegalThreshold)
+	}
+	if uint64(len(psigs)) != 2*t-1 {
+		return nil, fmt.Errorf("%s: %w, need %d, got %d", prefix, ErrWrongPartialSigNum, 2*t-1, len(psigs))
+	}
+	xs := make([]*big.Int, len(psigs))
+	seen := make(map[uint64]bool, len(psigs))
+	for j, ps := range psigs {
+		if ps == nil {
+			return nil, fmt.Errorf("%s: %w, nil partial signature at index %d", prefix, ErrInvalidPartialSig, j)
+		}
+		if seen[ps.id] {
+			return nil, fmt.Errorf("%s: %w, signer id %d", prefix, ErrDuplicatePartialSig, ps.id)
+		}
+		seen[ps.id] = true
+		if ps.inG1 != m.InG1 || len(ps.ts) != m.Len() {
+			return nil, fmt.Errorf("%s: %w, signer id %d does not match message", prefix, ErrInvalidPartialSig, ps.id)
+		}
+		xs[j] = new(big.Int).SetUint64(ps.id)
+	}
+	lags := make([]*big.Int, len(psigs))
+	for j := range psigs {
+		lags[j] = shamir.LagCoeff(xs[j], xs, bn.Order)
+	}
+	// interpolate 在指数上计算Σλ_j*elem(j)
+	interpolate := func(inG1 bool, elem func(j int) any) (any, error) {
+		res := utils.ScalarBaseMult(inG1, big.NewInt(0))
+		for j := range psigs {
+			e, err := utils.ScalarMult(elem(j), lags[j])
+			if err != nil {
+				return nil, err
+			}
+			if res, err = utils.Add(res, e); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+
+	mu := big.NewInt(0)
+	for j, ps := range psigs {
+		mu = utils.AddMod(mu, utils.MulMod(lags[j], ps.mu))
+	}
+	muInv := new(big.Int).ModInverse(mu, bn.Order)
+	if muInv == nil {
+		return nil, fmt.Errorf("%s: %w, u*a is zero", prefix, ErrInvalidPartialSig)
+	}
+	ra, err := interpolate(!m.InG1, func(j int) any { return psigs[j].ra })
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	sig := &Signature{STG1: m.InG1, ts: make([]any, m.Len())}
+	if sig.r, err = utils.ScalarMult(ra, muInv); err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	if sig.s, err = interpolate(m.InG1, func(j int) any { return psigs[j].s }); err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	for i := range sig.ts {
+		if sig.ts[i], err = interpolate(m.InG1, func(j int) any { return psigs[j].ts[i] }); err != nil {
+			return nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	if err = sig.Verify(sp, pk, m); err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", prefix, ErrInvalidPartialSig, err)
+	}
+
+	return sig, nil
+}
+
+// Marshal marshals ks as version || id || sk, id is a big endian uint64 and sk is a fixed-width scalar.
+// The result is secret key material and must be stored as such.
+func (ks *KeyShare) Marshal() []byte {
+	res := make([]byte, 0, 1+8+utils.ScalarSizeByte)
+	res = append(res, thresholdEncodingV1)
+	res = binary.BigEndian.AppendUint64(res, ks.id)
+
+	return utils.AppendScalar(res, ks.sk)
+}
+
+// Unmarshal reads from byte slice buff produced by KeyShare.Marshal and sets ks to the result.
+func (ks *KeyShare) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal groth key share"
+	d := utils.NewDecoder(buff)
+	if err := readThresholdVersion(d); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	id, err := readSignerID(d)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	sk, err := d.ReadScalar()
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	ks.id, ks.sk = id, sk
+
+	return nil
+}
+
+// Marshal marshals msg as version || From || To || u || a || len(zs) || zs,
+// From and To are big endian uint64, len(zs) is a 4-byte big endian integer and scalars are fixed-width.
+func (msg *SignShareMsg) Marshal() []byte {
+	res := make([]byte, 0, 1+16+4+(2+len(msg.zs))*utils.ScalarSizeByte)
+	res = append(res, thresholdEncodingV1)
+	res = binary.BigEndian.AppendUint64(res, msg.From)
+	res = binary.BigEndian.AppendUint64(res, msg.To)
+	res = utils.AppendScalar(res, msg.u)
+	res = utils.AppendScalar(res, msg.a)
+	res = binary.BigEndian.AppendUint32(res, uint32(len(msg.zs)))
+	for _, z := range msg.zs {
+		res = utils.AppendScalar(res, z)
+	}
+
+	return res
+}
+
+// Unmarshal reads from byte slice buff produced by SignShareMsg.Marshal and sets msg to the result.
+func (msg *SignShareMsg) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal groth sign share message"
+	d := utils.NewDecoder(buff)
+	if err := readThresholdVersion(d); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	res := new(SignShareMsg)
+	var err error
+	if res.From, err = readSignerID(d); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.To, err = readSignerID(d); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.u, err = d.ReadScalar(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.a, err = d.ReadScalar(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	nzs, err := d.ReadCount(utils.ScalarSizeByte)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	res.zs = make([]*big.Int, nzs)
+	for i := range res.zs {
+		if res.zs[i], err = d.ReadScalar(); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	*msg = *res
+
+	return nil
+}
+
+// Marshal marshals ps as version || id || inG1 || mu || ra || s || len(ts) || ts,
+// id is a big endian uint64, mu is a fixed-width scalar and len(ts) is a 4-byte big endian integer.
+func (ps *PartialSignature) Marshal() []byte {
+	res := []byte{thresholdEncodingV1}
+	res = binary.BigEndian.AppendUint64(res, ps.id)
+	res = utils.AppendBool(res, ps.inG1)
+	res = utils.AppendScalar(res, ps.mu)
+	res, _ = utils.AppendElem(res, ps.ra)
+	res, _ = utils.AppendElem(res, ps.s)
+	res = binary.BigEndian.AppendUint32(res, uint32(len(ps.ts)))
+	for _, t := range ps.ts {
+		res, _ = utils.AppendElem(res, t)
+	}
+
+	return res
+}
+
+// Unmarshal reads from byte slice buff produced by PartialSignature.Marshal and sets ps to the result,
+// ra must be in G2 if inG1 is true, s and ts in G1, and the other way around otherwise.
+func (ps *PartialSignature) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal groth partial signature"
+	d := utils.NewDecoder(buff)
+	if err := readThresholdVersion(d); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	res := new(PartialSignature)
+	var err error
+	if res.id, err = readSignerID(d); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.inG1, err = d.ReadBool(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.mu, err = d.ReadScalar(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.ra, err = d.ReadElem(!res.inG1); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.s, err = d.ReadElem(res.inG1); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	nts, err := d.ReadCount(1)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if nts == 0 {
+		return fmt.Errorf("%s: %w", prefix, ErrEmptyTs)
+	}
+	res.ts = make([]any, nts)
+	for i := range res.ts {
+		if res.ts[i], err = d.ReadElem(res.inG1); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	*ps = *res
+
+	return nil
+}
+
+// readThresholdVersion reads and checks the encoding version byte.
+func readThresholdVersion(d *utils.Decoder) error {
+	version, err := d.ReadByte()
+	if err != nil {
+		return err
+	}
+	if version != thresholdEncodingV1 {
+		return fmt.Errorf("%w %d", ErrUnknownThresholdVer, version)
+	}
+
+	return nil
+}
+
+// readSignerID reads a non-zero signer id.
+func readSignerID(d *utils.Decoder) (uint64, error) {
+	id, err := d.ReadUint64()
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, ErrZeroSignerID
+	}
+
+	return id, nil
+}
+
+func containsSigner(ids []uint64, id uint64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package grouputils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bn "github.com/cloudflare/bn256"
+)
+
+var (
+	ErrShortBuffer    = errors.New("buffer too short")
+	ErrTrailingBytes  = errors.New("unexpected trailing bytes")
+	ErrIllegalBool    = errors.New("illegal bool byte, must be 0 or 1")
+	ErrMalformedPoint = errors.New("malformed group element")
+	ErrScalarRange    = errors.New("scalar out of range, must be less than bn256.Order")
+)
+
+const ScalarSizeByte = 32 // 序列化后标量的固定字节数
+
+// AppendBool appends b to buff as a single byte, 1 for true and 0 for false.
+func AppendBool(buff []byte, b bool) []byte {
+	if b {
+		return append(buff, 1)
+	}
+	return append(buff, 0)
+}
+
+// AppendScalar appends k to buff as a ScalarSizeByte big endian integer,
+// k must be in [0, bn256.Order).
+func AppendScalar(buff []byte, k *big.Int) []byte {
+	return append(buff, k.FillBytes(make([]byte, ScalarSizeByte))...)
+}
+
+// AppendElem appends the marshaled a to buff, a must be in G1 or G2.
+func AppendElem(buff []byte, a any) ([]byte, error) {
+	// Marshal会将元素转换为仿射坐标，因此先复制一份，避免修改共享的元素
+	c, err := Copy(a)
+	if err != nil {
+		return nil, err
+	}
+	switch v := c.(type) {
+	case *bn.G1:
+		return append(buff, v.Marshal()...), nil
+	case *bn.G2:
+		return append(buff, v.Marshal()...), nil
+	default:
+		return nil, ErrIllegalGroupType
+	}
+}
+
+// Decoder 按顺序从字节切片中读取序列化的数据，读取越界时返回 ErrShortBuffer 而不会panic
+type Decoder struct {
+	buff []byte
+}
+
+// NewDecoder returns a Decoder reading from buff.
+func NewDecoder(buff []byte) *Decoder {
+	return &Decoder{buff}
+}
+
+// Len returns the number of unread bytes.
+func (d *Decoder) Len() int {
+	return len(d.buff)
+}
+
+// ReadBytes reads the next n bytes.
+func (d *Decoder) ReadBytes(n int) ([]byte, error) {
+	if n < 0 || len(d.buff) < n {
+		return nil, fmt.Errorf("%w, need %d bytes, %d left", ErrShortBuffer, n, len(d.buff))
+	}
+	res := d.buff[:n]
+	d.buff = d.buff[n:]
+
+	return res, nil
+}
+
+// ReadByte reads the next byte.
+func (d *Decoder) ReadByte() (byte, error) {
+	b, err := d.ReadBytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// ReadBool reads the next byte as a bool written by AppendBool.
+func (d *Decoder) ReadBool() (bool, error) {
+	b, err := d.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	switch b {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, ErrIllegalBool
+	}
+}
+
+// ReadUint32 reads the next 4 bytes as a big endian uint32.
+func (d *Decoder) ReadUint32() (uint32, error) {
+	b, err := d.ReadBytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// ReadUint64 reads the next 8 bytes as a big endian uint64.
+func (d *Decoder) ReadUint64() (uint64, error) {
+	b, err := d.ReadBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// ReadCount reads a uint32 element count and checks that the remaining buffer
+// can hold count elements of at least minSize bytes each.
+func (d *Decoder) ReadCount(minSize int) (int, error) {
+	n, err := d.ReadUint32()
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n)*uint64(minSize) > uint64(len(d.buff)) {
+		return 0, fmt.Errorf("%w, %d elements declared, %d bytes left", ErrShortBuffer, n, len(d.buff))
+	}
+	return int(n), nil
+}
+
+// ReadScalar reads the next ScalarSizeByte bytes as a scalar less than bn256.Order.
+func (d *Decoder) ReadScalar() (*big.Int, error) {
+	b, err := d.ReadBytes(ScalarSizeByte)
+	if err != nil {
+		return nil, err
+	}
+	k := new(big.Int).SetBytes(b)
+	if k.Cmp(bn.Order) >= 0 {
+		return nil, ErrScalarRange
+	}
+
+	return k, nil
+}
+
+// ReadG1 reads the next G1 element. The encoding must be canonical.
+func (d *Decoder) ReadG1() (*bn.G1, error) {
+	if len(d.buff) < G1SizeByte {
+		return nil, fmt.Errorf("%w, need %d bytes for G1, %d left", ErrShortBuffer, G1SizeByte, len(d.buff))
+	}
+	raw := d.buff[:G1SizeByte]
+	g := new(bn.G1)
+	if _, err := g.Unmarshal(raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPoint, err)
+	}
+	if !bytes.Equal(new(bn.G1).Set(g).Marshal(), raw) {
+		return nil, fmt.Errorf("%w: non-canonical G1 encoding", ErrMalformedPoint)
+	}
+	d.buff = d.buff[G1SizeByte:]
+
+	return g, nil
+}
+
+// ReadG2 reads the next G2 element. The encoding must be canonical and the
+// element must be in the prime order subgroup.
+func (d *Decoder) ReadG2() (*bn.G2, error) {
+	if len(d.buff) == 0 {
+		return nil, fmt.Errorf("%w, need at least 1 byte for G2", ErrShortBuffer)
+	}
+	size := G2SizeByte
+	if d.buff[0] == 0 {
+		size = 1 // 无穷远点
+	}
+	if len(d.buff) < size {
+		return nil, fmt.Errorf("%w, need %d bytes for G2, %d left", ErrShortBuffer, size, len(d.buff))
+	}
+	raw := d.buff[:size]
+	g := new(bn.G2)
+	if _, err := g.Unmarshal(raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPoint, err)
+	}
+	if !bytes.Equal(new(bn.G2).Set(g).Marshal(), raw) {
+		return nil, fmt.Errorf("%w: non-canonical G2 encoding", ErrMalformedPoint)
+	}
+	if !IsInfinity(new(bn.G2).ScalarMult(g, bn.Order)) {
+		return nil, fmt.Errorf("%w: G2 element not in subgroup", ErrMalformedPoint)
+	}
+	d.buff = d.buff[size:]
+
+	return g, nil
+}
+
+// ReadElem reads the next element in G1 if inG1 is true, otherwise in G2.
+func (d *Decoder) ReadElem(inG1 bool) (any, error) {
+	if inG1 {
+		return d.ReadG1()
+	}
+	return d.ReadG2()
+}
+
+// Finish returns ErrTrailingBytes if there are unread bytes left.
+func (d *Decoder) Finish() error {
+	if len(d.buff) != 0 {
+		return fmt.Errorf("%w, %d bytes left", ErrTrailingBytes, len(d.buff))
+	}
+	return nil
+}
+
+// IsInfinity checks if a is the identity element of G1 or G2.
+func IsInfinity(a serializable) bool {
+	switch v := a.(type) {
+	case *bn.G1:
+		return bytes.Equal(new(bn.G1).Set(v).Marshal(), make([]byte, G1SizeByte))
+	case *bn.G2:
+		return bytes.Equal(new(bn.G2).Set(v).Marshal(), []byte{0})
+	default:
+		return false
+	}
+}
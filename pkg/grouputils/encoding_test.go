@@ -0,0 +1,134 @@
+package grouputils
+
+import (
+	"math/big"
+	"testing"
+
+	bn "github.com/cloudflare/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+
+	g1 := NewG1(big.NewInt(7))
+	g2 := NewG2(big.NewInt(7))
+	g2Inf := new(bn.G2).ScalarBaseMult(big.NewInt(0))
+
+	var buff []byte
+	buff = AppendBool(buff, true)
+	buff = append(buff, 0, 0, 0, 3)
+	buff = AppendScalar(buff, big.NewInt(42))
+	var err error
+	for _, a := range []any{g1, g2Inf, g2} {
+		buff, err = AppendElem(buff, a)
+		require.NoError(t, err)
+	}
+
+	d := NewDecoder(buff)
+	b, err := d.ReadBool()
+	require.NoError(t, err)
+	require.True(t, b)
+	n, err := d.ReadUint32()
+	require.NoError(t, err)
+	require.EqualValues(t, 3, n)
+	k, err := d.ReadScalar()
+	require.NoError(t, err)
+	require.EqualValues(t, 42, k.Int64())
+	rg1, err := d.ReadG1()
+	require.NoError(t, err)
+	require.True(t, Equals(g1, rg1))
+	rg2Inf, err := d.ReadG2()
+	require.NoError(t, err)
+	require.True(t, IsInfinity(rg2Inf))
+	rg2, err := d.ReadElem(false)
+	require.NoError(t, err)
+	require.True(t, Equals(g2, rg2.(*bn.G2)))
+	require.NoError(t, d.Finish())
+}
+
+func TestDecoderMalformed(t *testing.T) {
+	t.Parallel()
+
+	g1 := NewG1(big.NewInt(7)).Marshal()
+	g2 := NewG2(big.NewInt(7)).Marshal()
+	offCurveG1 := append([]byte{}, g1...)
+	offCurveG1[len(offCurveG1)-1] ^= 1
+	offCurveG2 := append([]byte{}, g2...)
+	offCurveG2[len(offCurveG2)-1] ^= 1
+
+	testCases := []struct {
+		name string
+		buff []byte
+		read func(d *Decoder) error
+		err  error
+	}{
+		{
+			"truncated G1",
+			g1[:G1SizeByte-1],
+			func(d *Decoder) error { _, err := d.ReadG1(); return err },
+			ErrShortBuffer,
+		},
+		{
+			"truncated G2",
+			g2[:G2SizeByte-1],
+			func(d *Decoder) error { _, err := d.ReadG2(); return err },
+			ErrShortBuffer,
+		},
+		{
+			"G1 off curve",
+			offCurveG1,
+			func(d *Decoder) error { _, err := d.ReadG1(); return err },
+			ErrMalformedPoint,
+		},
+		{
+			"G2 off curve",
+			offCurveG2,
+			func(d *Decoder) error { _, err := d.ReadG2(); return err },
+			ErrMalformedPoint,
+		},
+		{
+			"scalar equal to order",
+			bn.Order.FillBytes(make([]byte, ScalarSizeByte)),
+			func(d *Decoder) error { _, err := d.ReadScalar(); return err },
+			ErrScalarRange,
+		},
+		{
+			"truncated scalar",
+			make([]byte, ScalarSizeByte-1),
+			func(d *Decoder) error { _, err := d.ReadScalar(); return err },
+			ErrShortBuffer,
+		},
+		{
+			"illegal bool",
+			[]byte{2},
+			func(d *Decoder) error { _, err := d.ReadBool(); return err },
+			ErrIllegalBool,
+		},
+		{
+			"count exceeds buffer",
+			[]byte{0, 0, 0, 2, 0},
+			func(d *Decoder) error { _, err := d.ReadCount(1); return err },
+			ErrShortBuffer,
+		},
+		{
+			"trailing bytes",
+			[]byte{1, 0},
+			func(d *Decoder) error {
+				if _, err := d.ReadBool(); err != nil {
+					return err
+				}
+				return d.Finish()
+			},
+			ErrTrailingBytes,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorIs(t, tc.read(NewDecoder(tc.buff)), tc.err)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package grouputils
+
+import (
+	"math/big"
+
+	bn "github.com/cloudflare/bn256"
+)
+
+// EArg 配对乘积中的一项e(A, B)^C，C为nil时表示e(A, B)
+type EArg struct {
+	A *bn.G1
+	B *bn.G2
+	C *big.Int
+}
+
+// NewEArg 创建e(g1, g2)^c对应的 EArg，g1与g2分别来自G1、G2，顺序不限
+func NewEArg(g1, g2 any, c *big.Int) *EArg {
+	ea := new(EArg)
+	if v, ok := g1.(*bn.G1); ok {
+		ea.A = v
+		ea.B = g2.(*bn.G2)
+	} else {
+		ea.A = g2.(*bn.G1)
+		ea.B = g1.(*bn.G2)
+	}
+	ea.C = c
+
+	return ea
+}
+
+// EProdFn 计算已将指数C作用于A之后的配对乘积
+type EProdFn func(pairs []*EArg) *bn.GT
+
+// EProduct 计算Πe(A_i, B_i)^C_i，nil项被忽略，fn决定具体的计算方式
+func EProduct(args []*EArg, fn EProdFn) *bn.GT {
+	pairs := make([]*EArg, 0, len(args))
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+
+		n := &EArg{
+			A: new(bn.G1).Set(arg.A),
+			B: new(bn.G2).Set(arg.B),
+		}
+		if arg.C != nil {
+			n.A.ScalarMult(n.A, arg.C)
+		}
+		// bn.Miller没有处理无穷远点，而e(A, B)此时为单位元，直接跳过
+		if IsInfinity(n.A) || IsInfinity(n.B) {
+			continue
+		}
+
+		pairs = append(pairs, n)
+	}
+
+	return fn(pairs)
+}
+
+// IsEProductOne 检查Πe(A_i, B_i)^C_i是否为GT的单位元，所有Miller loop共享一次最终幂运算
+func IsEProductOne(args []*EArg) bool {
+	return Equals(EProduct(args, EProdOptMillerLoopUnroll), GTOne())
+}
+
+// GTOne 返回GT的单位元
+func GTOne() *bn.GT {
+	return new(bn.GT).ScalarBaseMult(big.NewInt(0))
+}
+
+// EProdOptMiller 累加各项的Miller loop结果后进行一次最终幂运算
+func EProdOptMiller(pairs []*EArg) *bn.GT {
+	res := GTOne()
+	for _, pair := range pairs {
+		res.Add(res, bn.Miller(pair.A, pair.B))
+	}
+
+	return res.Finalize()
+}
+
+// EProdOptMillerLoopUnroll 与 EProdOptMiller 相同，但每次循环处理两项
+func EProdOptMillerLoopUnroll(pairs []*EArg) *bn.GT {
+	res := GTOne()
+	for i := 0; i < len(pairs); i += 2 {
+		var e *bn.GT
+		if i == len(pairs)-1 {
+			e = bn.Miller(pairs[i].A, pairs[i].B)
+		} else {
+			e = new(bn.GT).Add(
+				bn.Miller(pairs[i].A, pairs[i].B),
+				bn.Miller(pairs[i+1].A, pairs[i+1].B),
+			)
+		}
+		res.Add(res, e)
+	}
+
+	return res.Finalize()
+}
+
+// EProdNoOpt 逐项计算完整的配对再相乘，用于测试与对比
+func EProdNoOpt(pairs []*EArg) *bn.GT {
+	res := GTOne()
+	for _, arg := range pairs {
+		bk := bn.Pair(arg.A, arg.B)
+		if arg.C != nil {
+			bk.ScalarMult(bk, arg.C)
+		}
+		res.Add(res, bk)
+	}
+	return res
+}
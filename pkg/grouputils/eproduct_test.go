@@ -0,0 +1,75 @@
+package grouputils
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	bn "github.com/cloudflare/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+const numEArgs = 100
+
+func BenchmarkEProdNoOpt(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		args := randEArgs(numEArgs)
+		b.StartTimer()
+		EProdNoOpt(args)
+	}
+}
+
+func BenchmarkEProdOptMiller(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		args := randEArgs(numEArgs)
+		b.StartTimer()
+		EProduct(args, EProdOptMiller)
+	}
+}
+
+func BenchmarkEProdOptMillerLoopUnroll(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		args := randEArgs(numEArgs)
+		b.StartTimer()
+		EProduct(args, EProdOptMillerLoopUnroll)
+	}
+}
+
+func TestEProduct(t *testing.T) {
+	t.Parallel()
+	args := randEArgs(100)
+	require.True(t, Equals(EProduct(args, EProdOptMiller), EProdNoOpt(args)))
+	require.True(t, Equals(EProduct(args, EProdOptMillerLoopUnroll), EProdNoOpt(args)))
+}
+
+func TestIsEProductOne(t *testing.T) {
+	t.Parallel()
+	_, g1, _ := bn.RandomG1(rand.Reader)
+	_, g2, _ := bn.RandomG2(rand.Reader)
+	c, _ := rand.Int(rand.Reader, bn.Order)
+	// e(g1, g2)^c * e(g1^c, g2)^-1 * e(0, g2) == 1
+	args := []*EArg{
+		{g1, g2, c},
+		NewEArg(g2, new(bn.G1).ScalarMult(g1, c), AddInv(big.NewInt(1), bn.Order)),
+		{NewG1(big.NewInt(0)), g2, nil},
+		nil,
+	}
+	require.True(t, IsEProductOne(args))
+	args[0].C = AddMod(c, big.NewInt(1))
+	require.False(t, IsEProductOne(args))
+}
+
+func randEArgs(n int) []*EArg {
+	res := make([]*EArg, n)
+	for i := range res {
+		_, g1, _ := bn.RandomG1(rand.Reader)
+		_, g2, _ := bn.RandomG2(rand.Reader)
+		c, _ := rand.Int(rand.Reader, bn.Order)
+		res[i] = &EArg{g1, g2, c}
+	}
+
+	return res
+}
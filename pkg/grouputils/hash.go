@@ -0,0 +1,160 @@
+package grouputils
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	bn "github.com/cloudflare/bn256"
+)
+
+var ErrEmptyDST = errors.New("domain separation tag must not be empty")
+
+var (
+	// fieldP bn256基域的特征p，cloudflare/bn256没有导出该常数
+	fieldP, _ = new(big.Int).SetString(
+		"65000549695646603732796438742359905742825358107623003571877145026864184071783",
+		10,
+	)
+	// twistCofactor G2所在扭曲线E'(F_p²)的余因子，|E'(F_p²)| = n * (2p - n)
+	twistCofactor = new(big.Int).Sub(new(big.Int).Lsh(fieldP, 1), bn.Order)
+	// twistB 扭曲线y² = x³ + b'中的b'，由G2生成元的坐标计算得到
+	twistB = func() *fp2 {
+		x, y := g2Coords(new(bn.G2).ScalarBaseMult(big.NewInt(1)))
+		return x.square().mul(x).neg().add(y.square())
+	}()
+)
+
+// HashToG1 在域分离标签dst下将msg哈希到G1。使用cloudflare/bn256中基于SW编码的 bn.HashG1
+// 以dst || 0与dst || 1得到两个点后相加，使结果在G1中近似均匀分布。G1的余因子为1，无需清除
+func HashToG1(msg, dst []byte) (*bn.G1, error) {
+	if len(dst) == 0 {
+		return nil, ErrEmptyDST
+	}
+	d := append(append(make([]byte, 0, len(dst)+1), dst...), 0)
+	p := bn.HashG1(msg, d)
+	d[len(dst)] = 1
+
+	return p.Add(p, bn.HashG1(msg, d)), nil
+}
+
+// HashToG2 在域分离标签dst下将msg哈希到G2。使用try-and-increment：由msg与计数器派生F_p²中的x，
+// 直至x³ + b'为平方元，再将扭曲线上的点(x, y)乘以余因子得到G2中的元素
+func HashToG2(msg, dst []byte) (*bn.G2, error) {
+	if len(dst) == 0 {
+		return nil, ErrEmptyDST
+	}
+	buff := make([]byte, 1+4*32)
+	buff[0] = 0x01
+	for ctr := uint32(0); ; ctr++ {
+		x := &fp2{hashToFp(msg, dst, ctr, 0), hashToFp(msg, dst, ctr, 1)}
+		y, ok := x.square().mul(x).add(twistB).sqrt()
+		if !ok {
+			continue
+		}
+		x.a.FillBytes(buff[1:33])
+		x.b.FillBytes(buff[33:65])
+		y.a.FillBytes(buff[65:97])
+		y.b.FillBytes(buff[97:129])
+
+		g := new(bn.G2)
+		if _, err := g.Unmarshal(buff); err != nil {
+			continue
+		}
+		g.ScalarMult(g, twistCofactor)
+		if !IsInfinity(g) {
+			return g, nil
+		}
+	}
+}
+
+// hashToFp 将dst、msg、ctr与j哈希为64字节后模p，得到F_p中近似均匀分布的元素
+func hashToFp(msg, dst []byte, ctr uint32, j byte) *big.Int {
+	var out []byte
+	for k := byte(0); k < 2; k++ {
+		h := sha256.New()
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(dst))))
+		h.Write(dst)
+		h.Write(msg)
+		h.Write(binary.BigEndian.AppendUint32(nil, ctr))
+		h.Write([]byte{j, k})
+		out = h.Sum(out)
+	}
+
+	return new(big.Int).Mod(new(big.Int).SetBytes(out), fieldP)
+}
+
+// g2Coords 返回g的仿射坐标
+func g2Coords(g *bn.G2) (x, y *fp2) {
+	m := g.Marshal()
+	coord := func(off int) *big.Int {
+		return new(big.Int).SetBytes(m[off : off+32])
+	}
+
+	return &fp2{coord(1), coord(33)}, &fp2{coord(65), coord(97)}
+}
+
+// fp2 F_p²中的元素a*i + b，i² = -1，与cloudflare/bn256的序列化顺序一致
+type fp2 struct {
+	a, b *big.Int
+}
+
+func (e *fp2) add(f *fp2) *fp2 {
+	return &fp2{
+		new(big.Int).Mod(new(big.Int).Add(e.a, f.a), fieldP),
+		new(big.Int).Mod(new(big.Int).Add(e.b, f.b), fieldP),
+	}
+}
+
+func (e *fp2) neg() *fp2 {
+	return &fp2{
+		new(big.Int).Mod(new(big.Int).Neg(e.a), fieldP),
+		new(big.Int).Mod(new(big.Int).Neg(e.b), fieldP),
+	}
+}
+
+// mul (a1*i + b1)(a2*i + b2) = (a1*b2 + a2*b1)*i + (b1*b2 - a1*a2)
+func (e *fp2) mul(f *fp2) *fp2 {
+	a := new(big.Int).Add(new(big.Int).Mul(e.a, f.b), new(big.Int).Mul(f.a, e.b))
+	b := new(big.Int).Sub(new(big.Int).Mul(e.b, f.b), new(big.Int).Mul(e.a, f.a))
+
+	return &fp2{a.Mod(a, fieldP), b.Mod(b, fieldP)}
+}
+
+func (e *fp2) square() *fp2 {
+	return e.mul(e)
+}
+
+// sqrt 计算e的平方根，e不是平方元时返回false。
+// 记s = sqrt(a² + b²)，则sqrt(e) = (a / 2x)*i + x，其中x = sqrt((b ± s) / 2)
+func (e *fp2) sqrt() (*fp2, bool) {
+	if e.a.Sign() == 0 {
+		if x := new(big.Int).ModSqrt(e.b, fieldP); x != nil {
+			return &fp2{new(big.Int), x}, true
+		}
+		x := new(big.Int).ModSqrt(new(big.Int).Sub(fieldP, e.b), fieldP)
+		return &fp2{x, new(big.Int)}, true
+	}
+
+	norm := new(big.Int).Add(new(big.Int).Mul(e.a, e.a), new(big.Int).Mul(e.b, e.b))
+	s := new(big.Int).ModSqrt(norm.Mod(norm, fieldP), fieldP)
+	if s == nil {
+		return nil, false
+	}
+	inv2 := new(big.Int).Rsh(new(big.Int).Add(fieldP, big.NewInt(1)), 1)
+	var x *big.Int
+	for _, t := range []*big.Int{new(big.Int).Add(e.b, s), new(big.Int).Sub(e.b, s)} {
+		t.Mul(t, inv2).Mod(t, fieldP)
+		if x = new(big.Int).ModSqrt(t, fieldP); x != nil {
+			break
+		}
+	}
+	if x == nil {
+		return nil, false
+	}
+	a := new(big.Int).ModInverse(new(big.Int).Lsh(x, 1), fieldP)
+	a.Mul(a, e.a).Mod(a, fieldP)
+
+	return &fp2{a, x}, true
+}
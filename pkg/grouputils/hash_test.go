@@ -0,0 +1,125 @@
+package grouputils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	bn "github.com/cloudflare/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+// 已知答案测试使用的域分离标签。HashToG1与HashToG2不是RFC 9380中的哈希方法，
+// 因此标签不采用RFC 9380的suite ID格式
+var (
+	katDSTG1 = []byte("TAAT-LIB-TEST-HASH-TO-G1")
+	katDSTG2 = []byte("TAAT-LIB-TEST-HASH-TO-G2")
+)
+
+// TestHashToCurveKnownAnswers 中的向量由本实现生成，仅用于发现哈希结果的回归变化，
+// 不是与其它实现互通的测试向量
+func TestHashToCurveKnownAnswers(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		msg string
+		g1  string
+		g2  string
+	}{
+		{
+			"",
+			"777a982174b5d31999e57e80f4c677d21570a1993fecbadadc07b49650072c99" +
+				"22596da996d31e5ede5b5e60b572c54b0294045133b90db25323fcd20080baec",
+			"01" +
+				"740ffd5e98e5397789510ae3d1af6bb55f46862cd75c6020aac1fe529729ead4" +
+				"7485ccc8fc467de2be6686cf04373c29fa101a0b46dd768fca3ddfe7eb5cef29" +
+				"2c4b8664facf7eea2987603d637ab4717d8255b7a29efa722d5b8179b6189b72" +
+				"2cc32b802bcec1603fd58e8c5118a71cd6766fa065550fac928804ac2756e4db",
+		},
+		{
+			"abc",
+			"493e7346e610cc65c42d71818c205a4f34d9863d299b2e369098146a3a016e19" +
+				"375c22d1045209502b4f2977ee3e5f62b8883ce250a5bb8e0fb799ea6dc74ae5",
+			"01" +
+				"21da0d90128278d16b95c8b3d594678010ddb25e40c5652611339d4d9f49632e" +
+				"330a8b80e4a0fc4862f7ea1b82f7c0c5c57982ed960687e4923633c3750d4316" +
+				"8b0a1cdfe9bf4e2bd5fbd5708564e2e6f54c72f8e421602417606b36130d5b35" +
+				"0688abcf20b6ad9e55cd486471f1f9ef40a9b5fc25c3e37e28fa197f4ddf097a",
+		},
+		{
+			"abcdef0123456789",
+			"34dd27269e6943b11f86c03e4205f91b354b1bbb6358df801dce9cab00270892" +
+				"020d78747208325a6c7c67561bb991aa67b12c72bdc534e722e0b0eb0a9c91f4",
+			"01" +
+				"11228330cc1dd963316f5bb6f4a2e06523fe9101ffa10e0bceb2a6b8f29705b3" +
+				"451c8c0141b36a75afd3d46e33772f3009d70624131c542c476454c5d376eaee" +
+				"4dd1cac4deaba3c06bdeb2295ec203fd242167669a2df47555bde5195827e6cb" +
+				"81c177a38804840726db1c9238b99a894b5a5dc3752bda33b79e102942909363",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.msg, func(t *testing.T) {
+			t.Parallel()
+			g1, err := HashToG1([]byte(tc.msg), katDSTG1)
+			require.NoError(t, err)
+			require.Equal(t, tc.g1, hex.EncodeToString(g1.Marshal()))
+			g2, err := HashToG2([]byte(tc.msg), katDSTG2)
+			require.NoError(t, err)
+			require.Equal(t, tc.g2, hex.EncodeToString(g2.Marshal()))
+		})
+	}
+}
+
+func TestHashToCurve(t *testing.T) {
+	t.Parallel()
+
+	msg := []byte("taat-lib")
+	g1, err := HashToG1(msg, katDSTG1)
+	require.NoError(t, err)
+	g2, err := HashToG2(msg, katDSTG2)
+	require.NoError(t, err)
+	require.False(t, IsInfinity(g1))
+	require.False(t, IsInfinity(g2))
+	// G2的余因子已被清除，结果位于阶为n的子群中
+	require.True(t, IsInfinity(new(bn.G2).ScalarMult(g2, bn.Order)))
+
+	// 不同的消息或域分离标签得到不同的点
+	other1, err := HashToG1(msg, katDSTG2)
+	require.NoError(t, err)
+	require.False(t, Equals(g1, other1))
+	other2, err := HashToG2(append(msg, 0), katDSTG2)
+	require.NoError(t, err)
+	require.False(t, Equals(g2, other2))
+
+	_, err = HashToG1(msg, nil)
+	require.ErrorIs(t, err, ErrEmptyDST)
+	_, err = HashToG2(msg, []byte{})
+	require.ErrorIs(t, err, ErrEmptyDST)
+}
+
+func TestFp2Sqrt(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 32; i++ {
+		a, err := rand.Int(rand.Reader, fieldP)
+		require.NoError(t, err)
+		b, err := rand.Int(rand.Reader, fieldP)
+		require.NoError(t, err)
+		if i == 0 {
+			a.SetInt64(0)
+		}
+		x := &fp2{a, b}
+		sq := x.square()
+		r, ok := sq.sqrt()
+		require.True(t, ok)
+		require.Zero(t, r.square().a.Cmp(sq.a))
+		require.Zero(t, r.square().b.Cmp(sq.b))
+	}
+
+	// 非平方元：ξ = i + 3在F_p²中不是平方元
+	_, ok := (&fp2{big.NewInt(1), big.NewInt(3)}).sqrt()
+	require.False(t, ok)
+}
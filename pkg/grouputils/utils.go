@@ -87,7 +87,8 @@ func Neg(a any) (aNeg any, err error) {
 	case *bn.G1:
 		return new(bn.G1).Neg(v), nil
 	case *bn.G2:
-		return NewG2(new(big.Int).Mod(big.NewInt(-1), bn.Order)), nil
+		// bn.G2.Neg没有正确设置twistPoint.t，结果无法用于配对运算，因此使用a^(Order-1)
+		return new(bn.G2).ScalarMult(v, new(big.Int).Sub(bn.Order, big.NewInt(1))), nil
 	default:
 		return nil, ErrIllegalGroupType
 	}
@@ -262,3 +262,50 @@ func TestPair(t *testing.T) {
 		})
 	}
 }
+
+func TestNeg(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		a    any
+		ans  serializable
+		err  error
+	}{
+		{
+			"a in G1",
+			NewG1(big.NewInt(128)),
+			NewG1(AddInv(big.NewInt(128), bn256.Order)),
+			nil,
+		},
+		{
+			"a in G2",
+			NewG2(big.NewInt(128)),
+			NewG2(AddInv(big.NewInt(128), bn256.Order)),
+			nil,
+		},
+		{
+			"a neither in G1 nor G2",
+			"invalid a",
+			nil,
+			ErrIllegalGroupType,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			res, err := Neg(tc.a)
+			if tc.ans != nil {
+				require.NoError(t, err)
+				v, ok := res.(serializable)
+				require.True(t, ok)
+				require.True(t, Equals(v, tc.ans))
+			} else {
+				require.Nil(t, res)
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package shamir
+
+import (
+	"errors"
+	"math/big"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+)
+
+var (
+	ErrMalformedCommitment = errors.New("malformed polynomial commitment")
+	ErrInvalidShare        = errors.New("share is inconsistent with the commitment")
+)
+
+// Commitment is a Feldman commitment to a sharing polynomial over bn.Order,
+// G1s[k] = g1^a_k and, if present, G2s[k] = g2^a_k for every coefficient a_k.
+type Commitment struct {
+	G1s []*bn.G1
+	G2s []*bn.G2
+}
+
+// NewShare build a share with index x and value y.
+func NewShare(x, y *big.Int) Share {
+	return Share{x, y}
+}
+
+// Commit return the Feldman commitment to coeffs with base g1 in G1,
+// the G2 part is computed with base g2 only when g2 is not nil.
+func Commit(coeffs []*big.Int, g1 *bn.G1, g2 *bn.G2) *Commitment {
+	c := &Commitment{G1s: make([]*bn.G1, len(coeffs))}
+	for k, a := range coeffs {
+		c.G1s[k] = new(bn.G1).ScalarMult(g1, a)
+	}
+	if g2 != nil {
+		c.G2s = make([]*bn.G2, len(coeffs))
+		for k, a := range coeffs {
+			c.G2s[k] = new(bn.G2).ScalarMult(g2, a)
+		}
+	}
+
+	return c
+}
+
+// Deal generate n verifiable shares of secret with threshold t over bn.Order,
+// together with the commitment to the sharing polynomial.
+func Deal(t, n uint64, secret *big.Int, g1 *bn.G1, g2 *bn.G2) ([]Share, *Commitment) {
+	coeffs := GenRandPoly(t, secret, bn.Order)
+
+	return GenShares(coeffs, n, bn.Order), Commit(coeffs, g1, g2)
+}
+
+// Threshold return the number of shares needed to reconstruct the committed secret.
+func (c *Commitment) Threshold() int {
+	return len(c.G1s)
+}
+
+// Secret return g1^secret, the commitment to the constant term.
+func (c *Commitment) Secret() *bn.G1 {
+	return c.G1s[0]
+}
+
+// Eval return g1^f(x) computed from the commitment.
+func (c *Commitment) Eval(x *big.Int) *bn.G1 {
+	res := utils.NewG1(big.NewInt(0))
+	xk := big.NewInt(1)
+	for _, e := range c.G1s {
+		res.Add(res, new(bn.G1).ScalarMult(e, xk))
+		xk = utils.MulMod(xk, x)
+	}
+
+	return res
+}
+
+// EvalG2 return g2^f(x) computed from the G2 part of the commitment,
+// the commitment must contain the G2 part.
+func (c *Commitment) EvalG2(x *big.Int) *bn.G2 {
+	res := utils.NewG2(big.NewInt(0))
+	xk := big.NewInt(1)
+	for _, e := range c.G2s {
+		res.Add(res, new(bn.G2).ScalarMult(e, xk))
+		xk = utils.MulMod(xk, x)
+	}
+
+	return res
+}
+
+// Validate check that the commitment is well-formed w.r.t. bases g1 and g2:
+// it must not be empty or contain nil elements, and if it has a G2 part,
+// each G2s[k] must commit to the same coefficient as G1s[k].
+func (c *Commitment) Validate(g1 *bn.G1, g2 *bn.G2) error {
+	if c == nil || len(c.G1s) == 0 {
+		return ErrMalformedCommitment
+	}
+	for _, e := range c.G1s {
+		if e == nil {
+			return ErrMalformedCommitment
+		}
+	}
+	if c.G2s == nil {
+		return nil
+	}
+	if len(c.G2s) != len(c.G1s) || g2 == nil {
+		return ErrMalformedCommitment
+	}
+	for k, e := range c.G2s {
+		if e == nil || !utils.Equals(bn.Pair(c.G1s[k], g2), bn.Pair(g1, e)) {
+			return ErrMalformedCommitment
+		}
+	}
+
+	return nil
+}
+
+// VerifyShare check that share lies on the polynomial committed by c with base g1,
+// i.e. g1^y == c.Eval(x). The commitment should be validated by Validate first.
+func VerifyShare(share Share, c *Commitment, g1 *bn.G1) error {
+	if share.x == nil || share.y == nil || share.x.Sign() == 0 {
+		return ErrInvalidShare
+	}
+	if !utils.Equals(new(bn.G1).ScalarMult(g1, share.y), c.Eval(share.x)) {
+		return ErrInvalidShare
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package shamir
+
+import (
+	"math/big"
+	"testing"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyShare(t *testing.T) {
+	t.Parallel()
+
+	g1, g2 := utils.G1Generator(), utils.G2Generator()
+	secret := big.NewInt(42)
+	shares, comm := Deal(3, 5, secret, g1, g2)
+	require.NoError(t, comm.Validate(g1, g2))
+	require.Equal(t, 3, comm.Threshold())
+	require.True(t, utils.Equals(utils.NewG1(secret), comm.Secret()))
+	require.Zero(t, secret.Cmp(Reconstruct(shares[1:4], bn.Order)))
+
+	testCases := []struct {
+		name  string
+		share Share
+		err   error
+	}{
+		{
+			"valid share",
+			shares[2],
+			nil,
+		},
+		{
+			"wrong value",
+			NewShare(shares[2].X(), utils.AddMod(shares[2].Y(), big.NewInt(1))),
+			ErrInvalidShare,
+		},
+		{
+			"wrong index",
+			NewShare(shares[3].X(), shares[2].Y()),
+			ErrInvalidShare,
+		},
+		{
+			"zero index",
+			NewShare(big.NewInt(0), secret),
+			ErrInvalidShare,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := VerifyShare(tc.share, comm, g1)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, utils.Equals(utils.NewG2(tc.share.Y()), comm.EvalG2(tc.share.X())))
+		})
+	}
+}
+
+func TestCommitmentValidate(t *testing.T) {
+	t.Parallel()
+
+	g1, g2 := utils.G1Generator(), utils.G2Generator()
+	coeffs := GenRandPoly(3, big.NewInt(7), bn.Order)
+	testCases := []struct {
+		name   string
+		mutate func(c *Commitment)
+		err    error
+	}{
+		{
+			"well-formed",
+			func(c *Commitment) {},
+			nil,
+		},
+		{
+			"empty",
+			func(c *Commitment) { c.G1s = nil },
+			ErrMalformedCommitment,
+		},
+		{
+			"nil element",
+			func(c *Commitment) { c.G1s[1] = nil },
+			ErrMalformedCommitment,
+		},
+		{
+			"length mismatch",
+			func(c *Commitment) { c.G2s = c.G2s[:2] },
+			ErrMalformedCommitment,
+		},
+		{
+			"inconsistent G2 part",
+			func(c *Commitment) { c.G2s[1] = utils.G2Generator() },
+			ErrMalformedCommitment,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := Commit(coeffs, g1, g2)
+			tc.mutate(c)
+			err := c.Validate(g1, g2)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
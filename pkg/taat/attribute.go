@@ -1,18 +1,72 @@
 package taat
 
 import (
+	"crypto/sha512"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
 
 	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 	bn "github.com/cloudflare/bn256"
 )
 
+var (
+	ErrIllegalAttrLevel = errors.New("illegal attribute level, must be greater than 0")
+	ErrIllegalAttrIndex = errors.New("illegal attribute index")
+	ErrDuplicateAttr    = errors.New("duplicate disclosed attribute")
+	ErrNilAttr          = errors.New("nil attribute")
+)
+
+// attrHashDST 属性值映射的域分离标签
+const attrHashDST = "TAAT-V01-ATTRIBUTE"
+
+// 属性值的类型标签，不同类型的相同字节表示会映射为不同的 Attribute
+const (
+	attrTypeBytes byte = iota
+	attrTypeInt
+)
+
 // Attribute 是 Credential 证书的属性
 type Attribute struct {
 	attr1 *bn.G1
 	attr2 *bn.G2
 }
 
+// NewAttributeFromBytes 将字节串value确定性地映射为 Attribute，
+// 即attr1 = g1^k，attr2 = g2^k，其中k = HASH(value) mod bn256.Order
+func NewAttributeFromBytes(value []byte) *Attribute {
+	return newAttribute(attrTypeBytes, value)
+}
+
+// NewAttributeFromString 将字符串value确定性地映射为 Attribute，
+// 与 NewAttributeFromBytes([]byte(value)) 的结果相同
+func NewAttributeFromString(value string) *Attribute {
+	return newAttribute(attrTypeBytes, []byte(value))
+}
+
+// NewAttributeFromInt 将整数value确定性地映射为 Attribute
+func NewAttributeFromInt(value int64) *Attribute {
+	return newAttribute(attrTypeInt, binary.BigEndian.AppendUint64(nil, uint64(value)))
+}
+
+func newAttribute(typ byte, value []byte) *Attribute {
+	h := sha512.New()
+	h.Write([]byte(attrHashDST))
+	h.Write([]byte{typ})
+	h.Write(value)
+	k := new(big.Int).SetBytes(h.Sum(nil))
+	k.Mod(k, bn.Order)
+
+	return &Attribute{utils.NewG1(k), utils.NewG2(k)}
+}
+
+// Equals checks if a == b.
+func (a *Attribute) Equals(b *Attribute) bool {
+	return utils.Equals(a.attr1, b.attr1) && utils.Equals(a.attr2, b.attr2)
+}
+
 func (a *Attribute) G1() *bn.G1 {
 	return a.attr1
 }
@@ -27,6 +81,16 @@ type AttrSetElem struct {
 	value *Attribute
 }
 
+// Level returns the level of the credential holding the attribute.
+func (ase *AttrSetElem) Level() int {
+	return ase.i
+}
+
+// Index returns the index of the attribute in its credential.
+func (ase *AttrSetElem) Index() int {
+	return ase.j
+}
+
 func (ase *AttrSetElem) Value() *Attribute {
 	return ase.value
 }
@@ -50,7 +114,7 @@ func (as AttrSet) Len() int {
 }
 
 func (as AttrSet) Less(i, j int) bool {
-	return as[i].i < as[j].i || as[i].j < as[j].j
+	return as[i].i < as[j].i || (as[i].i == as[j].i && as[i].j < as[j].j)
 }
 
 func (as AttrSet) Swap(i, j int) {
@@ -74,3 +138,53 @@ func (as AttrSet) Marshal() []byte {
 
 	return res
 }
+
+// AttrSetBuilder 用于构造规范排序的 AttrSet，由于 CredProof 的挑战值依赖于 AttrSet 的序列化顺序，
+// 证明者与验证者都应使用 AttrSetBuilder 构造被披露的属性集合
+type AttrSetBuilder struct {
+	maxAttrs int
+	elems    AttrSet
+	err      error
+}
+
+// NewAttrSet 创建一个新的 AttrSetBuilder，被披露属性的下标须小于sp.MaxAttrs
+func NewAttrSet(sp *Parameters) *AttrSetBuilder {
+	return &AttrSetBuilder{maxAttrs: sp.MaxAttrs}
+}
+
+// Disclose 披露第level层 Credential 中的第index个属性attr，level从1开始，index从0开始，
+// 出现的第一个错误会在 AttrSetBuilder.Build 时返回
+func (b *AttrSetBuilder) Disclose(level, index int, attr *Attribute) *AttrSetBuilder {
+	const prefix = "failed to disclose attribute"
+	if b.err != nil {
+		return b
+	}
+
+	switch {
+	case level < 1:
+		b.err = fmt.Errorf("%s: %w, got %d", prefix, ErrIllegalAttrLevel, level)
+	case index < 0 || index >= b.maxAttrs:
+		b.err = fmt.Errorf("%s: %w, must be in [0, %d), got %d", prefix, ErrIllegalAttrIndex, b.maxAttrs, index)
+	case attr == nil || attr.attr1 == nil || attr.attr2 == nil:
+		b.err = fmt.Errorf("%s: %w, at level %d index %d", prefix, ErrNilAttr, level, index)
+	case b.elems.Get(level, index) != nil:
+		b.err = fmt.Errorf("%s: %w, at level %d index %d", prefix, ErrDuplicateAttr, level, index)
+	default:
+		b.elems = append(b.elems, &AttrSetElem{level, index, attr})
+	}
+
+	return b
+}
+
+// Build 返回按(level, index)升序排列的 AttrSet
+func (b *AttrSetBuilder) Build() (AttrSet, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	res := make(AttrSet, len(b.elems))
+	copy(res, b.elems)
+	sort.Sort(res)
+
+	return res, nil
+}
@@ -0,0 +1,203 @@
+package taat
+
+import (
+	"testing"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAttribute(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		a, b  *Attribute
+		equal bool
+	}{
+		{
+			"same bytes",
+			NewAttributeFromBytes([]byte("age>=18")),
+			NewAttributeFromBytes([]byte("age>=18")),
+			true,
+		},
+		{
+			"string and bytes of the same value",
+			NewAttributeFromString("age>=18"),
+			NewAttributeFromBytes([]byte("age>=18")),
+			true,
+		},
+		{
+			"same int",
+			NewAttributeFromInt(-42),
+			NewAttributeFromInt(-42),
+			true,
+		},
+		{
+			"different strings",
+			NewAttributeFromString("role=admin"),
+			NewAttributeFromString("role=user"),
+			false,
+		},
+		{
+			"different ints",
+			NewAttributeFromInt(1),
+			NewAttributeFromInt(2),
+			false,
+		},
+		{
+			"int and bytes of the same encoding",
+			NewAttributeFromInt(1),
+			NewAttributeFromBytes([]byte{0, 0, 0, 0, 0, 0, 0, 1}),
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.equal, tc.a.Equals(tc.b))
+			for _, a := range []*Attribute{tc.a, tc.b} {
+				// attr1与attr2须具有相同的离散对数
+				require.True(t, utils.Equals(
+					bn.Pair(a.G1(), utils.G2Generator()),
+					bn.Pair(utils.G1Generator(), a.G2()),
+				))
+			}
+		})
+	}
+}
+
+func TestDiscloseConstructedAttribute(t *testing.T) {
+	t.Parallel()
+
+	sp, secrets, err := Setup(2, 3, 2)
+	require.NoError(t, err)
+	usk, upk := NewUserKeyPair(1)
+	attrs := []*Attribute{NewAttributeFromString("country=CN"), NewAttributeFromInt(1990)}
+	cred, err := NewRootCredential(sp.RootUPK).Delegate(sp, secrets.RootUSK, upk, attrs)
+	require.NoError(t, err)
+
+	nymSK, nymPK, err := NewNymKeyPair(usk, sp.H2)
+	require.NoError(t, err)
+	nonce := []byte("nonce")
+	proof, err := NewCredProof(sp, cred, usk, nymSK, AttrSet{&AttrSetElem{1, 0, attrs[0]}}, nonce)
+	require.NoError(t, err)
+
+	// 验证者独立构造同一属性值
+	disclosed := AttrSet{&AttrSetElem{1, 0, NewAttributeFromString("country=CN")}}
+	require.NoError(t, proof.Verify(sp, disclosed, nymPK, nonce))
+	disclosed = AttrSet{&AttrSetElem{1, 0, NewAttributeFromString("country=US")}}
+	require.ErrorIs(t, proof.Verify(sp, disclosed, nymPK, nonce), ErrIncorrectCredProof)
+}
+
+func TestAttrSetBuilder(t *testing.T) {
+	t.Parallel()
+
+	sp := &Parameters{MaxAttrs: 3}
+	a := NewAttributeFromString("a")
+	testCases := []struct {
+		name    string
+		build   func(b *AttrSetBuilder) *AttrSetBuilder
+		indices [][2]int
+		err     error
+	}{
+		{
+			"canonical order",
+			func(b *AttrSetBuilder) *AttrSetBuilder {
+				return b.Disclose(2, 0, a).Disclose(1, 2, a).Disclose(1, 0, a).Disclose(2, 1, a)
+			},
+			[][2]int{{1, 0}, {1, 2}, {2, 0}, {2, 1}},
+			nil,
+		},
+		{
+			"empty set",
+			func(b *AttrSetBuilder) *AttrSetBuilder { return b },
+			[][2]int{},
+			nil,
+		},
+		{
+			"illegal level",
+			func(b *AttrSetBuilder) *AttrSetBuilder { return b.Disclose(0, 0, a) },
+			nil,
+			ErrIllegalAttrLevel,
+		},
+		{
+			"index out of range",
+			func(b *AttrSetBuilder) *AttrSetBuilder { return b.Disclose(1, 3, a) },
+			nil,
+			ErrIllegalAttrIndex,
+		},
+		{
+			"negative index",
+			func(b *AttrSetBuilder) *AttrSetBuilder { return b.Disclose(1, -1, a) },
+			nil,
+			ErrIllegalAttrIndex,
+		},
+		{
+			"nil attribute",
+			func(b *AttrSetBuilder) *AttrSetBuilder { return b.Disclose(1, 0, nil) },
+			nil,
+			ErrNilAttr,
+		},
+		{
+			"duplicate attribute",
+			func(b *AttrSetBuilder) *AttrSetBuilder {
+				return b.Disclose(1, 0, a).Disclose(2, 0, a).Disclose(1, 0, a)
+			},
+			nil,
+			ErrDuplicateAttr,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			as, err := tc.build(NewAttrSet(sp)).Build()
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				require.Nil(t, as)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, as, len(tc.indices))
+			for i, idx := range tc.indices {
+				require.Equal(t, idx[0], as[i].Level())
+				require.Equal(t, idx[1], as[i].Index())
+			}
+		})
+	}
+}
+
+func TestAttrSetBuilderOrderIndependentProof(t *testing.T) {
+	t.Parallel()
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+	creds, usks := newTestCredChain(t, sp, secrets.RootUSK, 2)
+	cred, usk := creds[2], usks[2]
+	nymSK, nymPK, err := NewNymKeyPair(usk, sp.H1)
+	require.NoError(t, err)
+
+	// 证明者与验证者以不同顺序披露相同的属性
+	proverSet, err := NewAttrSet(sp).
+		Disclose(2, 1, cred.attrs[1]).
+		Disclose(1, 2, creds[1].attrs[2]).
+		Disclose(2, 0, cred.attrs[0]).
+		Build()
+	require.NoError(t, err)
+	verifierSet, err := NewAttrSet(sp).
+		Disclose(1, 2, creds[1].attrs[2]).
+		Disclose(2, 0, cred.attrs[0]).
+		Disclose(2, 1, cred.attrs[1]).
+		Build()
+	require.NoError(t, err)
+
+	nonce := []byte("nonce")
+	proof, err := NewCredProof(sp, cred, usk, nymSK, proverSet, nonce)
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(sp, verifierSet, nymPK, nonce))
+}
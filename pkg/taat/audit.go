@@ -1,7 +1,6 @@
 package taat
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"errors"
 	"fmt"
@@ -56,8 +55,8 @@ func NewAuditProof(
 
 	proof := new(AuditProof)
 
-	// 生成Hash(com1, com2, com3, nymPK, cttbe)，并转换成*big.Int
-	c := new(big.Int).SetBytes(auditProveHash(com1, com2, com3, nymPK, cttbe))
+	// 生成Hash(com1, com2, com3, nymPK, cttbe) mod bn256.Order
+	c := auditProveHash(com1, com2, com3, nymPK, cttbe)
 	proof.c = c
 
 	proof.p1 = utils.AddMod(rhos[0], utils.MulMod(c, usk))
@@ -95,13 +94,29 @@ func (ap *AuditProof) Verify(cttbe *ttbe.Cttbe, tpk *ttbe.TPK, nymPK *PK, h any)
 	npkc, _ := utils.ScalarMult(nymPK.pk, cInv)
 	com3, _ = utils.Add(com3, npkc)
 
-	if !bytes.Equal(auditProveHash(com1, com2, com3, nymPK, cttbe), ap.c.Bytes()) {
+	if ap.c.Cmp(auditProveHash(com1, com2, com3, nymPK, cttbe)) != 0 {
 		return fmt.Errorf("failed to verify audit proof: %w", ErrIncorrectAuditProof)
 	}
 
 	return nil
 }
 
+// Marshal marshals AuditProof as c || p1 || p2 || p3, each a fixed-width scalar.
+func (ap *AuditProof) Marshal() []byte {
+	return marshalScalars(ap.c, ap.p1, ap.p2, ap.p3)
+}
+
+// Unmarshal unmarshals buff produced by AuditProof.Marshal, every scalar must be less than bn256.Order.
+func (ap *AuditProof) Unmarshal(buff []byte) error {
+	ks, err := unmarshalScalars(buff, 4)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal audit proof: %w", err)
+	}
+	ap.c, ap.p1, ap.p2, ap.p3 = ks[0], ks[1], ks[2], ks[3]
+
+	return nil
+}
+
 // inSameGroup 检查nymPK、h、cttbe是否在同一个群中，G1或者G2
 func inSameGroup(cttbe *ttbe.Cttbe, nymPK *PK, h any) bool {
 	if cttbe.InG1 {
@@ -117,8 +132,8 @@ func inSameGroup(cttbe *ttbe.Cttbe, nymPK *PK, h any) bool {
 	return true
 }
 
-// auditProveHash 生成Hash(com1, com2, com3, nymPK, cttbe)
-func auditProveHash(com1, com2, com3 any, nymPK *PK, cttbe *ttbe.Cttbe) (sum []byte) {
+// auditProveHash 生成Hash(com1, com2, com3, nymPK, cttbe) mod bn256.Order
+func auditProveHash(com1, com2, com3 any, nymPK *PK, cttbe *ttbe.Cttbe) *big.Int {
 	h := sha256.New()
 	if cttbe.InG1 {
 		h.Write(com1.(*bn.G1).Marshal())
@@ -132,6 +147,7 @@ func auditProveHash(com1, com2, com3 any, nymPK *PK, cttbe *ttbe.Cttbe) (sum []b
 		h.Write(nymPK.pk.(*bn.G2).Marshal())
 	}
 	h.Write(cttbe.Marshal())
+	c := new(big.Int).SetBytes(h.Sum(nil))
 
-	return h.Sum(nil)
+	return c.Mod(c, bn.Order)
 }
@@ -1,6 +1,7 @@
 package taat
 
 import (
+	"bytes"
 	"crypto/rand"
 	"math/big"
 	"testing"
@@ -181,3 +182,32 @@ func newAudParams(inG1 bool) *audParams {
 
 	return params
 }
+
+func TestAuditProofMarshal(t *testing.T) {
+	t.Parallel()
+
+	params := newAudParams(true)
+	proof, err := NewAuditProof(
+		params.tpk,
+		params.cttbe,
+		params.r1,
+		params.r2,
+		params.usk,
+		params.nymSK,
+		params.nymPK,
+		params.h,
+	)
+	require.NoError(t, err)
+	buff := proof.Marshal()
+	require.Len(t, buff, 4*utils.ScalarSizeByte)
+
+	proof2 := new(AuditProof)
+	require.NoError(t, proof2.Unmarshal(buff))
+	require.NoError(t, proof2.Verify(params.cttbe, params.tpk, params.nymPK, params.h))
+
+	require.ErrorIs(t, new(AuditProof).Unmarshal(buff[:len(buff)-1]), utils.ErrShortBuffer)
+	require.ErrorIs(t, new(AuditProof).Unmarshal(append(buff, 0)), utils.ErrTrailingBytes)
+	outOfRange := append([]byte{}, buff...)
+	copy(outOfRange[3*utils.ScalarSizeByte:], bytes.Repeat([]byte{0xff}, utils.ScalarSizeByte))
+	require.ErrorIs(t, new(AuditProof).Unmarshal(outOfRange), utils.ErrScalarRange)
+}
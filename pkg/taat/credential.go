@@ -1,12 +1,13 @@
 package taat
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/TomCN0803/taat-lib/pkg/groth"
-	bn "github.com/cloudflare/bn256"
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 )
 
 var (
@@ -15,8 +16,13 @@ var (
 	ErrInconsistentRootPK = errors.New("inconsistent root public key")
 	ErrWrongUPK           = errors.New("wrong upk for this usk")
 	ErrIllegalLevel       = errors.New("illegal level")
+	ErrUnknownCredVersion = errors.New("unknown credential encoding version")
+	ErrMalformedCred      = errors.New("malformed credential encoding")
 )
 
+// credEncodingV1 Credential 序列化格式的版本号
+const credEncodingV1 byte = 1
+
 // Credential 用户的证书，L-level的用户证书包括：
 //  1. 自身的（L层）sig（groth签名）、attrs（属性）与pk（公钥）
 //  2. L层以下的（i...L-1层）的 Credential（prevCreds）
@@ -59,12 +65,64 @@ func (c *Credential) Delegate(sp *Parameters, sk *big.Int, upk *PK, attrs []*Att
 		return nil, fmt.Errorf("failed to delegate to level-%d user: %w", level, err)
 	}
 
+	return c.extend(sig, upk, attrs), nil
+}
+
+// DelegationMessage 返回从c授权给L层的upk与attrs时需要签名的Groth消息，
+// 私钥被门限分享时，签名者使用 groth.ThresholdSigner 对其签名
+func (c *Credential) DelegationMessage(upk *PK, attrs []*Attribute) (*groth.Message, error) {
+	level := len(c.prevCreds) + 1
+	m, err := c.newGrothMessage(level, upk, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create level-%d delegation message: %w", level, err)
+	}
+
+	return m, nil
+}
+
+// DelegateWithSignature 使用已产生的Groth签名sig给L层生成一个新的 Credential，
+// sig须为c中upk对 Credential.DelegationMessage 的有效签名，例如由 groth.CombinePartialSignatures 合并得到
+func (c *Credential) DelegateWithSignature(sp *Parameters, upk *PK, attrs []*Attribute, sig *groth.Signature) (*Credential, error) {
+	level := len(c.prevCreds) + 1
+	m, err := c.newGrothMessage(level, upk, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delegate to level-%d user: %w", level, err)
+	}
+	if err = sig.Verify(sp.Groth, c.upk.grothPK(), m); err != nil {
+		return nil, fmt.Errorf("failed to delegate to level-%d user: %w", level, err)
+	}
+
+	return c.extend(sig, upk, attrs), nil
+}
+
+// extend 返回在c之后追加一层的 Credential
+func (c *Credential) extend(sig *groth.Signature, upk *PK, attrs []*Attribute) *Credential {
+	// 复制证书链，避免从同一证书多次授权时共享底层数组
+	prevCreds := make([]*Credential, 0, len(c.prevCreds)+1)
+	prevCreds = append(prevCreds, c.prevCreds...)
+	prevCreds = append(prevCreds, c)
+
 	return &Credential{
 		sig:       sig,
 		attrs:     attrs,
 		upk:       upk,
-		prevCreds: append(c.prevCreds, c),
-	}, nil
+		prevCreds: prevCreds,
+	}
+}
+
+// Level returns the level of c in the delegation chain, 0 for the root credential.
+func (c *Credential) Level() int {
+	return len(c.prevCreds)
+}
+
+// UPK returns the public key certified by c.
+func (c *Credential) UPK() *PK {
+	return c.upk
+}
+
+// ProvePossession 产生 UskProof，向不持有usk的验证者证明用户持有c中upk对应的usk
+func (c *Credential) ProvePossession(usk *big.Int, nonce []byte) (*UskProof, error) {
+	return NewUSKProof(usk, c.upk, nonce)
 }
 
 // Verify 验证 Credential 的有效性，需要满足：
@@ -73,51 +131,194 @@ func (c *Credential) Delegate(sp *Parameters, sk *big.Int, upk *PK, attrs []*Att
 //  3. 本层证书中的upk是与usk对应的
 //  4. 证书链中每层的证书都是有效的
 func (c *Credential) Verify(sp *Parameters, level int, usk *big.Int, rootPK *PK) error {
+	if !c.upk.Verify(usk) {
+		return fmt.Errorf("failed to verify credential: %w", ErrWrongUPK)
+	}
+
+	return c.VerifyPublic(sp, level, rootPK, nil, nil)
+}
+
+// VerifyPublic 在不持有usk的情况下验证 Credential 的有效性，需要满足：
+//  1. 证书链中证书数量与level对应
+//  2. 根授权组织的公钥正确，以确保证书授权链来自信任的根
+//  3. 从根开始，证书链中每层的Groth签名都是有效的
+//  4. 若提供了proof，则proof须证明持有者在nonce下拥有本层upk对应的usk，见 Credential.ProvePossession
+func (c *Credential) VerifyPublic(sp *Parameters, level int, rootPK *PK, proof *UskProof, nonce []byte) error {
 	const prefix = "failed to verify credential"
 	if level != len(c.prevCreds) {
 		return fmt.Errorf("%s: %w, expected %d, got %d", prefix, ErrWrongCredNum, level, len(c.prevCreds))
 	}
 
-	if !rootPK.Equals(c.prevCreds[0].upk) {
-		return fmt.Errorf("%s: %w", prefix, ErrInconsistentRootPK)
+	root := c
+	if level > 0 {
+		root = c.prevCreds[0]
 	}
-
-	if !c.upk.Verify(usk) {
-		return fmt.Errorf("%s: %w", prefix, ErrWrongUPK)
+	if !rootPK.Equals(root.upk) {
+		return fmt.Errorf("%s: %w", prefix, ErrInconsistentRootPK)
 	}
 
-	for i := level; i > 0; i-- {
-		var curr, prev *Credential
-		if i == level {
-			curr = c
-		} else {
+	// 证书链中所有层的Groth签名合并为一次批量验证
+	pks := make([]*groth.PK, level)
+	gms := make([]*groth.Message, level)
+	sigs := make([]*groth.Signature, level)
+	for i := 1; i <= level; i++ {
+		curr := c
+		if i < level {
 			curr = c.prevCreds[i]
 		}
-		prev = c.prevCreds[i-1]
 
-		var (
-			ppk1 *bn.G1
-			ppk2 *bn.G2
-		)
-		if prev.upk.inG1 {
-			ppk1 = prev.upk.pk.(*bn.G1)
-		} else {
-			ppk2 = prev.upk.pk.(*bn.G2)
+		if err := curr.sig.Validate(sp.Groth); err != nil {
+			return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
 		}
-
 		gm, err := c.newGrothMessage(i, curr.upk, curr.attrs)
 		if err != nil {
 			return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
 		}
-		err = curr.sig.Verify(sp.Groth, groth.NewGrothPK(ppk1, ppk2), gm)
-		if err != nil {
-			return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+		pks[i-1], gms[i-1], sigs[i-1] = c.prevCreds[i-1].upk.grothPK(), gm, curr.sig
+	}
+	if err := groth.BatchVerify(sp.Groth, pks, gms, sigs); err != nil {
+		var be *groth.BatchError
+		if errors.As(err, &be) {
+			return fmt.Errorf("%s at level-%d: %w", prefix, be.Failed[0]+1, be.Errs[0])
+		}
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	if proof != nil {
+		if err := proof.Verify(c.upk, nonce); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+
+	return nil
+}
+
+// Marshal 将包含完整证书链的 Credential 序列化为：
+//
+//	version || level || rootPK || cred_1 || ... || cred_level
+//
+// 其中cred_i = upk || len(attrs) || attrs || sig，level与长度均为4字节大端整数，
+// sig的编码见 groth.Signature.Marshal
+func (c *Credential) Marshal() ([]byte, error) {
+	const prefix = "failed to marshal credential"
+	level := c.Level()
+	buff := []byte{credEncodingV1}
+	buff = binary.BigEndian.AppendUint32(buff, uint32(level))
+	root, _ := c.AtLevel(0)
+	buff = append(buff, root.upk.Marshal()...)
+
+	var err error
+	for i := 1; i <= level; i++ {
+		curr, _ := c.AtLevel(i)
+		buff = append(buff, curr.upk.Marshal()...)
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(curr.attrs)))
+		for _, attr := range curr.attrs {
+			if buff, err = utils.AppendElem(buff, attr.attr1); err != nil {
+				return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+			}
+			if buff, err = utils.AppendElem(buff, attr.attr2); err != nil {
+				return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+			}
+		}
+		if curr.sig == nil {
+			return nil, fmt.Errorf("%s at level-%d: %w, missing signature", prefix, i, ErrMalformedCred)
 		}
+		buff = append(buff, curr.sig.Marshal()...)
 	}
 
+	return buff, nil
+}
+
+// Unmarshal 反序列化由 Credential.Marshal 产生的buff并重建证书链，
+// 每层upk与签名所在的群须与层级对应，且所有群元素须是合法的点，
+// 但不验证签名，使用前仍需调用 Credential.Verify 或 Credential.VerifyPublic
+func (c *Credential) Unmarshal(buff []byte) error {
+	cred, err := unmarshalCredential(buff)
+	if err != nil {
+		return err
+	}
+	*c = *cred
+
 	return nil
 }
 
+func unmarshalCredential(buff []byte) (*Credential, error) {
+	const prefix = "failed to unmarshal credential"
+	d := utils.NewDecoder(buff)
+	version, err := d.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	if version != credEncodingV1 {
+		return nil, fmt.Errorf("%s: %w %d", prefix, ErrUnknownCredVersion, version)
+	}
+	// 每层证书的编码至少占用2字节，据此限制level以避免超大的内存分配
+	level, err := d.ReadCount(2)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	rootPK, err := decodePK(d)
+	if err != nil {
+		return nil, fmt.Errorf("%s at root: %w", prefix, err)
+	}
+	if !rootPK.inG1 {
+		return nil, fmt.Errorf("%s: %w, root upk must be in G1", prefix, ErrMalformedCred)
+	}
+	creds := make([]*Credential, level+1)
+	creds[0] = NewRootCredential(rootPK)
+
+	for i := 1; i <= level; i++ {
+		inG1 := i%2 == 0
+		upk, err := decodePK(d)
+		if err != nil {
+			return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+		}
+		if upk.inG1 != inG1 {
+			return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, ErrWrongUPKType)
+		}
+		nAttrs, err := d.ReadCount(utils.G1SizeByte + 1)
+		if err != nil {
+			return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+		}
+		attrs := make([]*Attribute, nAttrs)
+		for j := range attrs {
+			attrs[j] = new(Attribute)
+			if attrs[j].attr1, err = d.ReadG1(); err != nil {
+				return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+			}
+			if attrs[j].attr2, err = d.ReadG2(); err != nil {
+				return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+			}
+		}
+		sig, err := groth.ReadSignature(d)
+		if err != nil {
+			return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+		}
+		if sig.STG1 != inG1 || len(sig.Ts()) != nAttrs+1 {
+			return nil, fmt.Errorf(
+				"%s at level-%d: %w, signature does not match upk and %d attributes",
+				prefix,
+				i,
+				ErrMalformedCred,
+				nAttrs,
+			)
+		}
+
+		creds[i] = &Credential{
+			sig:       sig,
+			attrs:     attrs,
+			upk:       upk,
+			prevCreds: append(make([]*Credential, 0, i), creds[:i]...),
+		}
+	}
+	if err = d.Finish(); err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	return creds[level], nil
+}
+
 //// Prove calls NewCredProof.
 //func (c *Credential) Prove(sp *Parameters, usk, nymSK *big.Int, attrSet AttrSet, nonce []byte) (*CredProof, error) {
 //	return NewCredProof(sp, c, usk, nymSK, attrSet, nonce)
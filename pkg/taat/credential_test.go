@@ -2,9 +2,12 @@ package taat
 
 import (
 	"crypto/rand"
+	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/TomCN0803/taat-lib/pkg/groth"
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 	bn "github.com/cloudflare/bn256"
 	"github.com/stretchr/testify/require"
 )
@@ -28,7 +31,312 @@ func TestCredentialDelegation(t *testing.T) {
 		require.NoError(t, err)
 		err = cred.Verify(sp, i, usk, rootPK)
 		require.NoError(t, err)
-		preCred = cred
+		preCred, preSK = cred, usk
+	}
+}
+
+func TestCredentialVerifyPublic(t *testing.T) {
+	t.Parallel()
+
+	nonce := []byte("possession nonce")
+	testCases := []struct {
+		name      string
+		level     int
+		withProof bool
+		tamper    func(cred *Credential, proof *UskProof) (level int, rootPK *PK)
+		err       error
+	}{
+		{
+			"happy path without proof",
+			3,
+			false,
+			nil,
+			nil,
+		},
+		{
+			"happy path with proof",
+			2,
+			true,
+			nil,
+			nil,
+		},
+		{
+			"root credential",
+			0,
+			true,
+			nil,
+			nil,
+		},
+		{
+			"wrong level",
+			2,
+			false,
+			func(cred *Credential, proof *UskProof) (int, *PK) { return 3, nil },
+			ErrWrongCredNum,
+		},
+		{
+			"wrong root pk",
+			2,
+			false,
+			func(cred *Credential, proof *UskProof) (int, *PK) {
+				_, rootPK := NewUserKeyPair(0)
+				return 2, rootPK
+			},
+			ErrInconsistentRootPK,
+		},
+		{
+			"tampered attribute",
+			2,
+			false,
+			func(cred *Credential, proof *UskProof) (int, *PK) {
+				cred.prevCreds[1].attrs[0] = randNAttrs(1)[0]
+				return 2, nil
+			},
+			groth.ErrFailedMsgPredicate,
+		},
+		{
+			"incorrect possession proof",
+			1,
+			true,
+			func(cred *Credential, proof *UskProof) (int, *PK) {
+				proof.p.Add(proof.p, big.NewInt(1))
+				return 1, nil
+			},
+			ErrIncorrectUSKProof,
+		},
+	}
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			creds, usks := newTestCredChain(t, sp, secrets.RootUSK, tc.level)
+			cred := creds[tc.level]
+			require.Equal(t, tc.level, cred.Level())
+
+			var proof *UskProof
+			var err error
+			if tc.withProof {
+				proof, err = cred.ProvePossession(usks[tc.level], nonce)
+				require.NoError(t, err)
+			}
+			level, rootPK := tc.level, sp.RootUPK
+			if tc.tamper != nil {
+				var pk *PK
+				level, pk = tc.tamper(cred, proof)
+				if pk != nil {
+					rootPK = pk
+				}
+			}
+			err = cred.VerifyPublic(sp, level, rootPK, proof, nonce)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCredentialDelegateTwice(t *testing.T) {
+	t.Parallel()
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+	creds, usks := newTestCredChain(t, sp, secrets.RootUSK, 2)
+
+	// 从同一个证书多次授权，证书链之间不应相互影响
+	usk3a, upk3a := NewUserKeyPair(3)
+	cred3a, err := creds[2].Delegate(sp, usks[2], upk3a, randNAttrs(sp.MaxAttrs))
+	require.NoError(t, err)
+	usk3b, upk3b := NewUserKeyPair(3)
+	cred3b, err := creds[2].Delegate(sp, usks[2], upk3b, randNAttrs(sp.MaxAttrs))
+	require.NoError(t, err)
+
+	_, upk4 := NewUserKeyPair(4)
+	cred4, err := cred3a.Delegate(sp, usk3a, upk4, randNAttrs(sp.MaxAttrs))
+	require.NoError(t, err)
+	_, err = cred3b.Delegate(sp, usk3b, upk4, randNAttrs(sp.MaxAttrs))
+	require.NoError(t, err)
+
+	require.NoError(t, cred3a.Verify(sp, 3, usk3a, sp.RootUPK))
+	require.NoError(t, cred3b.Verify(sp, 3, usk3b, sp.RootUPK))
+	require.NoError(t, cred4.VerifyPublic(sp, 4, sp.RootUPK, nil, nil))
+}
+
+func TestCredentialThresholdDelegation(t *testing.T) {
+	t.Parallel()
+
+	const th, n = 2, 4
+	sp, _, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+	// 根授权组织的私钥被门限分享，不存在单独持有rootUSK的签名者
+	gpk, kss, err := groth.GenThresholdKey(nil, th, n)
+	require.NoError(t, err)
+	rootSP := *sp
+	rootSP.RootUPK = NewUPK(0, gpk)
+	sp = &rootSP
+	root := NewRootCredential(sp.RootUPK)
+
+	usk1, upk1 := NewUserKeyPair(1)
+	attrs1 := randNAttrs(sp.MaxAttrs)
+	m, err := root.DelegationMessage(upk1, attrs1)
+	require.NoError(t, err)
+	ids := []uint64{1, 3, 4}
+	signers := make([]*groth.ThresholdSigner, len(ids))
+	inbox := make(map[uint64][]*groth.SignShareMsg, len(ids))
+	for i, id := range ids {
+		signers[i], err = groth.NewThresholdSigner(sp.Groth, kss[id-1], th, ids, m)
+		require.NoError(t, err)
+		out, err := signers[i].Round1()
+		require.NoError(t, err)
+		for to, msg := range out {
+			inbox[to] = append(inbox[to], msg)
+		}
+	}
+	psigs := make([]*groth.PartialSignature, len(ids))
+	for i, id := range ids {
+		psigs[i], err = signers[i].Round2(inbox[id])
+		require.NoError(t, err)
+	}
+	sig, err := groth.CombinePartialSignatures(sp.Groth, gpk, m, psigs, th)
+	require.NoError(t, err)
+
+	_, err = root.DelegateWithSignature(sp, upk1, randNAttrs(sp.MaxAttrs), sig)
+	require.ErrorIs(t, err, groth.ErrFailedMsgPredicate)
+	cred1, err := root.DelegateWithSignature(sp, upk1, attrs1, sig)
+	require.NoError(t, err)
+	require.NoError(t, cred1.Verify(sp, 1, usk1, sp.RootUPK))
+
+	// 门限签发的证书可以继续正常授权，并用于产生 CredProof
+	usk2, upk2 := NewUserKeyPair(2)
+	attrs := [][]*Attribute{nil, attrs1, randNAttrs(sp.MaxAttrs)}
+	cred2, err := cred1.Delegate(sp, usk1, upk2, attrs[2])
+	require.NoError(t, err)
+	require.NoError(t, cred2.VerifyPublic(sp, 2, sp.RootUPK, nil, nil))
+	nymSK, nymPK, err := NewNymKeyPair(usk2, sp.H1)
+	require.NoError(t, err)
+	attrSet := randAttrSet(attrs, 2, sp.MaxAttrs)
+	nonce := []byte("threshold delegation")
+	proof, err := NewCredProof(sp, cred2, usk2, nymSK, attrSet, nonce)
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(sp, attrSet, nymPK, nonce))
+}
+
+func TestCredentialMarshal(t *testing.T) {
+	t.Parallel()
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+
+	for level := 0; level <= 3; level++ {
+		level := level
+		t.Run(fmt.Sprintf("level-%d", level), func(t *testing.T) {
+			t.Parallel()
+			creds, usks := newTestCredChain(t, sp, secrets.RootUSK, level)
+			buff, err := creds[level].Marshal()
+			require.NoError(t, err)
+
+			cred := new(Credential)
+			require.NoError(t, cred.Unmarshal(buff))
+			require.Equal(t, level, cred.Level())
+			require.NoError(t, cred.Verify(sp, level, usks[level], sp.RootUPK))
+			buff2, err := cred.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, buff, buff2)
+
+			// 反序列化得到的证书可以继续授权
+			_, upk := NewUserKeyPair(level + 1)
+			next, err := cred.Delegate(sp, usks[level], upk, randNAttrs(sp.MaxAttrs))
+			require.NoError(t, err)
+			require.NoError(t, next.VerifyPublic(sp, level+1, sp.RootUPK, nil, nil))
+		})
+	}
+}
+
+func TestCredentialSignatureOversized(t *testing.T) {
+	t.Parallel()
+
+	sp, secrets, err := Setup(5, 3, 2)
+	require.NoError(t, err)
+	creds, usks := newTestCredChain(t, sp, secrets.RootUSK, 1)
+	buff, err := creds[1].Marshal()
+	require.NoError(t, err)
+	cred := new(Credential)
+	require.NoError(t, cred.Unmarshal(buff))
+
+	// 参数只支持3个属性，而反序列化得到的证书签名了5个属性
+	small := *sp
+	small.MaxAttrs = 3
+	small.Groth = &groth.Parameters{Y1s: sp.Groth.Y1s[:4], Y2s: sp.Groth.Y2s[:4]}
+	require.ErrorIs(t, cred.VerifyPublic(&small, 1, sp.RootUPK, nil, nil), groth.ErrArgOverflow)
+	nymSK, _, err := NewNymKeyPair(usks[1], hOfPK(sp, cred.upk))
+	require.NoError(t, err)
+	_, err = NewCredProof(&small, cred, usks[1], nymSK, nil, []byte("nonce"))
+	require.ErrorIs(t, err, groth.ErrArgOverflow)
+}
+
+func TestUnmarshalCredentialMalformed(t *testing.T) {
+	t.Parallel()
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+	creds, _ := newTestCredChain(t, sp, secrets.RootUSK, 2)
+	buff, err := creds[2].Marshal()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		tamper func(b []byte) []byte
+		err    error
+	}{
+		{
+			"empty",
+			func(b []byte) []byte { return nil },
+			utils.ErrShortBuffer,
+		},
+		{
+			"unknown version",
+			func(b []byte) []byte { b[0] = 0xff; return b },
+			ErrUnknownCredVersion,
+		},
+		{
+			"truncated",
+			func(b []byte) []byte { return b[:len(b)-1] },
+			utils.ErrShortBuffer,
+		},
+		{
+			"trailing bytes",
+			func(b []byte) []byte { return append(b, 0) },
+			utils.ErrTrailingBytes,
+		},
+		{
+			"huge level",
+			func(b []byte) []byte { b[1] = 0xff; return b },
+			utils.ErrShortBuffer,
+		},
+		{
+			"illegal inG1 byte of root upk",
+			func(b []byte) []byte { b[5] = 2; return b },
+			ErrIllegalInG1Byte,
+		},
+		{
+			"root upk off curve",
+			func(b []byte) []byte { b[5+utils.G1SizeByte] ^= 1; return b },
+			utils.ErrMalformedPoint,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			b := tc.tamper(append([]byte{}, buff...))
+			require.ErrorIs(t, new(Credential).Unmarshal(b), tc.err)
+		})
 	}
 }
 
@@ -1,23 +1,29 @@
 package taat
 
 import (
-	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/TomCN0803/taat-lib/pkg/groth"
 	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	"github.com/TomCN0803/taat-lib/pkg/ttbe"
 	bn "github.com/cloudflare/bn256"
 )
 
 var (
 	ErrWrongGroupNymPK    = errors.New("wrong group of NymPK")
 	ErrIncorrectCredProof = errors.New("incorrect credential proof")
+	ErrUnknownProofVer    = errors.New("unknown credential proof encoding version")
+	ErrMalformedCredProof = errors.New("malformed credential proof encoding")
 )
 
+// credProofEncodingV1 CredProof 序列化格式的版本号
+const credProofEncodingV1 byte = 1
+
 // CredProof 关于 Credential 的证明
 type CredProof struct {
 	comm    *big.Int
@@ -26,6 +32,8 @@ type CredProof struct {
 	resUPK  []any
 	resUSK  *big.Int
 	resNym  *big.Int
+	resR1   *big.Int // TTBE加密随机数r1的响应值，仅在可审计的证明中存在
+	resR2   *big.Int // TTBE加密随机数r2的响应值，仅在可审计的证明中存在
 }
 
 type resSig struct {
@@ -34,12 +42,46 @@ type resSig struct {
 	resT   []any
 }
 
+// credAudit 可审计的 CredProof 中与TTBE密文相关的部分
+type credAudit struct {
+	cttbe        *ttbe.Cttbe
+	r1, r2       *big.Int // TTBE加密随机数，仅证明者持有
+	com1         any      // g^rhoUSK * U^(rhoR1+rhoR2)，对应C3
+	com2         any      // H^rhoR1，对应C1
+	com3         any      // V^rhoR2，对应C2
+	rhoR1, rhoR2 *big.Int
+	resR1, resR2 *big.Int
+}
+
 // NewCredProof 产生新的 CredProof
 func NewCredProof(
 	sp *Parameters, cred *Credential, usk, nymSK *big.Int, attrSet AttrSet, m []byte,
+) (*CredProof, error) {
+	return newCredProof(sp, cred, usk, nymSK, attrSet, nil, m)
+}
+
+// NewAuditableCredProof 产生可审计的 CredProof，除 NewCredProof 所证明的内容外，
+// 还在同一个Fiat-Shamir挑战下证明cttbe(由ttbe.Encrypt以随机数r1、r2产生)加密的是
+// cred中的upk，即cttbe.C1 == H^r1、cttbe.C2 == V^r2且cttbe.C3 == upk*U^r1*U^r2，
+// 且upk与nymPK使用同一个usk，从而保证审计者恢复出的身份就是证书持有者
+func NewAuditableCredProof(
+	sp *Parameters, cred *Credential, usk, nymSK *big.Int, attrSet AttrSet,
+	cttbe *ttbe.Cttbe, r1, r2 *big.Int, m []byte,
+) (*CredProof, error) {
+	if cttbe.InG1 != cred.upk.inG1 {
+		return nil, fmt.Errorf("failed to generate credential proof: %w", ErrCttbeAndPKsNotInSameGroup)
+	}
+	return newCredProof(sp, cred, usk, nymSK, attrSet, &credAudit{cttbe: cttbe, r1: r1, r2: r2}, m)
+}
+
+func newCredProof(
+	sp *Parameters, cred *Credential, usk, nymSK *big.Int, attrSet AttrSet, ca *credAudit, m []byte,
 ) (*CredProof, error) {
 	const prefix = "failed to generate credential proof"
 	level := len(cred.prevCreds)
+	if level == 0 {
+		return nil, fmt.Errorf("%s: %w, root credential cannot be proved", prefix, ErrIllegalLevel)
+	}
 	rhoSigmas := make([]*big.Int, level+1)
 	randSigs := make([]*groth.Signature, level+1)
 	rhoSs := make([]*big.Int, level+1)
@@ -52,6 +94,9 @@ func NewCredProof(
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", prefix, err)
 		}
+		if err = c.sig.Validate(sp.Groth); err != nil {
+			return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+		}
 
 		rho, err := rand.Int(rand.Reader, bn.Order)
 		if err != nil {
@@ -99,28 +144,28 @@ func NewCredProof(
 		g1neg, _ := utils.Neg(g1)
 		g2neg, _ := utils.Neg(g2)
 
-		eas1 := []*eArg{newEArg(g1, c.sig.R(), utils.MulMod(rhoSigmas[i], rhoSs[i]))}
-		eas2 := []*eArg{
-			newEArg(g1, c.sig.R(), utils.MulMod(rhoSigmas[i], rhoTSs[i][0])),
-			newEArg(g1, g2neg, rhoUPKs[i]),
+		eas1 := []*utils.EArg{utils.NewEArg(g1, c.sig.R(), utils.MulMod(rhoSigmas[i], rhoSs[i]))}
+		eas2 := []*utils.EArg{
+			utils.NewEArg(g1, c.sig.R(), utils.MulMod(rhoSigmas[i], rhoTSs[i][0])),
+			utils.NewEArg(g1, g2neg, rhoUPKs[i]),
 		}
 		if i != 1 {
-			eas1 = append(eas1, newEArg(g1neg, g2, rhoUPKs[i-1]))
+			eas1 = append(eas1, utils.NewEArg(g1neg, g2, rhoUPKs[i-1]))
 			y0 := yiAtLevel(sp, 0, i)
 			yneg, _ := utils.Neg(y0)
-			eas2 = append(eas2, newEArg(yneg, g2, rhoUPKs[i-1]))
+			eas2 = append(eas2, utils.NewEArg(yneg, g2, rhoUPKs[i-1]))
 		}
 		ec.enqueue(eas1, i, 0)
 		ec.enqueue(eas2, i, 1)
 
 		for j, rhoA := range rhoAttrs[i] {
-			eas := []*eArg{newEArg(g1, c.sig.R(), utils.MulMod(rhoSigmas[i], rhoTSs[i][j+1]))}
+			eas := []*utils.EArg{utils.NewEArg(g1, c.sig.R(), utils.MulMod(rhoSigmas[i], rhoTSs[i][j+1]))}
 			if i != 1 {
 				yneg, _ := utils.Neg(yiAtLevel(sp, j+1, i))
-				eas = append(eas, newEArg(yneg, g2, rhoUPKs[i-1]))
+				eas = append(eas, utils.NewEArg(yneg, g2, rhoUPKs[i-1]))
 			}
 			if attrSet.Get(i, j) == nil {
-				eas = append(eas, newEArg(g1, g2neg, rhoA))
+				eas = append(eas, utils.NewEArg(g1, g2neg, rhoA))
 			}
 			ec.enqueue(eas, i, j+2)
 		}
@@ -137,12 +182,23 @@ func NewCredProof(
 	} else {
 		cnym = utils.ProductOfExpG2(utils.G2Generator(), rhoUPKs[level], sp.H2, rhoNym)
 	}
+	if ca != nil {
+		ca.rhoR1, err = rand.Int(rand.Reader, bn.Order)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+		ca.rhoR2, err = rand.Int(rand.Reader, bn.Order)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+		ca.commit(sp.TPK, rhoUPKs[level])
+	}
 
 	rPrimes := make([]any, len(randSigs))
 	for i := 1; i < len(randSigs); i++ {
 		rPrimes[i] = randSigs[i].R()
 	}
-	comm := new(big.Int).SetBytes(hashCredComm(sp.RootUPK, rPrimes, cijs, cnym, attrSet, m))
+	comm := hashCredComm(sp.RootUPK, rPrimes, cijs, cnym, ca, attrSet, m)
 
 	resSigs := make([]*resSig, level+1)
 	resUPK := make([]any, level+1)
@@ -185,13 +241,75 @@ func NewCredProof(
 
 	resUSK := utils.AddMod(rhoUPKs[level], utils.MulMod(comm, usk))
 	resNym := utils.AddMod(rhoNym, utils.MulMod(comm, nymSK))
+	var resR1, resR2 *big.Int
+	if ca != nil {
+		resR1 = utils.AddMod(ca.rhoR1, utils.MulMod(comm, ca.r1))
+		resR2 = utils.AddMod(ca.rhoR2, utils.MulMod(comm, ca.r2))
+	}
+
+	return &CredProof{comm, resSigs, resAttr, resUPK, resUSK, resNym, resR1, resR2}, nil
+}
 
-	return &CredProof{comm, resSigs, resAttr, resUPK, resUSK, resNym}, nil
+// commit 计算证明者的承诺com1 = g^rhoUSK * U^(rhoR1+rhoR2)，com2 = H^rhoR1，com3 = V^rhoR2
+func (ca *credAudit) commit(tpk *ttbe.TPK, rhoUSK *big.Int) {
+	rhoR := utils.AddMod(ca.rhoR1, ca.rhoR2)
+	if ca.cttbe.InG1 {
+		ca.com1 = utils.ProductOfExpG1(utils.G1Generator(), rhoUSK, tpk.U1, rhoR)
+		ca.com2 = new(bn.G1).ScalarMult(tpk.H1, ca.rhoR1)
+		ca.com3 = new(bn.G1).ScalarMult(tpk.V1, ca.rhoR2)
+	} else {
+		ca.com1 = utils.ProductOfExpG2(utils.G2Generator(), rhoUSK, tpk.U2, rhoR)
+		ca.com2 = new(bn.G2).ScalarMult(tpk.H2, ca.rhoR1)
+		ca.com3 = new(bn.G2).ScalarMult(tpk.V2, ca.rhoR2)
+	}
+}
+
+// recommit 根据响应值恢复承诺com1 = g^resUSK * U^(resR1+resR2) * C3^-c，
+// com2 = H^resR1 * C1^-c，com3 = V^resR2 * C2^-c
+func (ca *credAudit) recommit(tpk *ttbe.TPK, resUSK, cneg *big.Int) {
+	resR := utils.AddMod(ca.resR1, ca.resR2)
+	if ca.cttbe.InG1 {
+		ca.com1 = new(bn.G1).Add(
+			utils.ProductOfExpG1(utils.G1Generator(), resUSK, tpk.U1, resR),
+			new(bn.G1).ScalarMult(ca.cttbe.C3.(*bn.G1), cneg),
+		)
+		ca.com2 = utils.ProductOfExpG1(tpk.H1, ca.resR1, ca.cttbe.C1.(*bn.G1), cneg)
+		ca.com3 = utils.ProductOfExpG1(tpk.V1, ca.resR2, ca.cttbe.C2.(*bn.G1), cneg)
+	} else {
+		ca.com1 = new(bn.G2).Add(
+			utils.ProductOfExpG2(utils.G2Generator(), resUSK, tpk.U2, resR),
+			new(bn.G2).ScalarMult(ca.cttbe.C3.(*bn.G2), cneg),
+		)
+		ca.com2 = utils.ProductOfExpG2(tpk.H2, ca.resR1, ca.cttbe.C1.(*bn.G2), cneg)
+		ca.com3 = utils.ProductOfExpG2(tpk.V2, ca.resR2, ca.cttbe.C2.(*bn.G2), cneg)
+	}
 }
 
 // Verify verifies a CredProof.
 func (cp *CredProof) Verify(sp *Parameters, attrSet AttrSet, nymPK *PK, nonce []byte) error {
+	if cp.resR1 != nil || cp.resR2 != nil {
+		return fmt.Errorf("failed to verify credential proof: %w, auditable proof requires VerifyAuditable", ErrIncorrectCredProof)
+	}
+	return cp.verify(sp, attrSet, nymPK, nil, nonce)
+}
+
+// VerifyAuditable verifies a CredProof generated by NewAuditableCredProof against cttbe.
+func (cp *CredProof) VerifyAuditable(sp *Parameters, attrSet AttrSet, nymPK *PK, cttbe *ttbe.Cttbe, nonce []byte) error {
+	const prefix = "failed to verify credential proof"
+	if cp.resR1 == nil || cp.resR2 == nil {
+		return fmt.Errorf("%s: %w, not an auditable proof", prefix, ErrIncorrectCredProof)
+	}
+	if cttbe.InG1 != nymPK.inG1 {
+		return fmt.Errorf("%s: %w", prefix, ErrCttbeAndPKsNotInSameGroup)
+	}
+	return cp.verify(sp, attrSet, nymPK, &credAudit{cttbe: cttbe, resR1: cp.resR1, resR2: cp.resR2}, nonce)
+}
+
+func (cp *CredProof) verify(sp *Parameters, attrSet AttrSet, nymPK *PK, ca *credAudit, nonce []byte) error {
 	const prefix = "failed to verify credential proof"
+	if err := cp.checkShape(sp, attrSet); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
 	level := len(cp.resSigs) - 1
 	cneg := utils.AddInv(cp.comm, bn.Order)
 
@@ -204,38 +322,38 @@ func (cp *CredProof) Verify(sp *Parameters, attrSet AttrSet, nymPK *PK, nonce []
 
 		rsig := cp.resSigs[i]
 		y0 := yiAtLevel(sp, 0, i)
-		eas1 := []*eArg{
-			newEArg(rsig.resS, rsig.rPrime, nil),
-			newEArg(y0, g2, cneg),
+		eas1 := []*utils.EArg{
+			utils.NewEArg(rsig.resS, rsig.rPrime, nil),
+			utils.NewEArg(y0, g2, cneg),
 		}
-		eas2 := []*eArg{newEArg(rsig.resT[0], rsig.rPrime, nil)}
+		eas2 := []*utils.EArg{utils.NewEArg(rsig.resT[0], rsig.rPrime, nil)}
 		if i == 1 {
-			eas1 = append(eas1, newEArg(g1, sp.RootUPK.pk, cneg))
-			eas2 = append(eas2, newEArg(y0, sp.RootUPK.pk, cneg))
+			eas1 = append(eas1, utils.NewEArg(g1, sp.RootUPK.pk, cneg))
+			eas2 = append(eas2, utils.NewEArg(y0, sp.RootUPK.pk, cneg))
 		} else {
-			eas1 = append(eas1, newEArg(g1neg, cp.resUPK[i-1], nil))
+			eas1 = append(eas1, utils.NewEArg(g1neg, cp.resUPK[i-1], nil))
 			yneg, _ := utils.Neg(y0)
-			eas2 = append(eas2, newEArg(yneg, cp.resUPK[i-1], nil))
+			eas2 = append(eas2, utils.NewEArg(yneg, cp.resUPK[i-1], nil))
 		}
 		if i == level {
-			eas2 = append(eas2, newEArg(g1, g2neg, cp.resUSK))
+			eas2 = append(eas2, utils.NewEArg(g1, g2neg, cp.resUSK))
 		} else {
-			eas2 = append(eas2, newEArg(cp.resUPK[i], g2neg, nil))
+			eas2 = append(eas2, utils.NewEArg(cp.resUPK[i], g2neg, nil))
 		}
 		ec.enqueue(eas1, i, 0)
 		ec.enqueue(eas2, i, 1)
 
 		for j := range cp.resAttr[i] {
-			eas := []*eArg{newEArg(rsig.resT[j+1], rsig.rPrime, nil)}
+			eas := []*utils.EArg{utils.NewEArg(rsig.resT[j+1], rsig.rPrime, nil)}
 			yj := yiAtLevel(sp, j+1, i)
 			yjneg, _ := utils.Neg(yj)
 			if i == 1 {
-				eas = append(eas, newEArg(yj, sp.RootUPK.pk, cneg))
+				eas = append(eas, utils.NewEArg(yj, sp.RootUPK.pk, cneg))
 			} else {
-				eas = append(eas, newEArg(yjneg, cp.resUPK[i-1], nil))
+				eas = append(eas, utils.NewEArg(yjneg, cp.resUPK[i-1], nil))
 			}
 			if attr := attrSet.Get(i, j); attr == nil {
-				eas = append(eas, newEArg(cp.resAttr[i][j], g2neg, nil))
+				eas = append(eas, utils.NewEArg(cp.resAttr[i][j], g2neg, nil))
 			} else {
 				var a any
 				if i%2 == 0 {
@@ -243,7 +361,7 @@ func (cp *CredProof) Verify(sp *Parameters, attrSet AttrSet, nymPK *PK, nonce []
 				} else {
 					a = attr.value.attr2
 				}
-				eas = append(eas, newEArg(a, g2, cneg))
+				eas = append(eas, utils.NewEArg(a, g2, cneg))
 			}
 			ec.enqueue(eas, i, j+2)
 		}
@@ -273,16 +391,219 @@ func (cp *CredProof) Verify(sp *Parameters, attrSet AttrSet, nymPK *PK, nonce []
 	for i := 1; i < len(cp.resSigs); i++ {
 		rPrimes[i] = cp.resSigs[i].rPrime
 	}
-	comm := hashCredComm(sp.RootUPK, rPrimes, cijs, cnym, attrSet, nonce)
+	if ca != nil {
+		ca.recommit(sp.TPK, cp.resUSK, cneg)
+	}
+	comm := hashCredComm(sp.RootUPK, rPrimes, cijs, cnym, ca, attrSet, nonce)
 
-	if !bytes.Equal(cp.comm.Bytes(), comm) {
+	if cp.comm.Cmp(comm) != 0 {
 		return fmt.Errorf("%s: %w", prefix, ErrIncorrectCredProof)
 	}
 
 	return nil
 }
 
-func hashCredComm(rootUPK *PK, rPrimes []any, cijs [][]*bn.GT, cnym serializable, attrSet AttrSet, m []byte) []byte {
+// checkShape 检查cp中各响应值的数量与attrSet、sp是否匹配，避免验证时出现越界或空值
+func (cp *CredProof) checkShape(sp *Parameters, attrSet AttrSet) error {
+	if cp.comm == nil || cp.resUSK == nil || cp.resNym == nil || len(cp.resSigs) == 0 {
+		return ErrIncorrectCredProof
+	}
+	level := len(cp.resSigs) - 1
+	if len(cp.resAttr) != level+1 || len(cp.resUPK) != level+1 {
+		return ErrIncorrectCredProof
+	}
+	for i := 1; i <= level; i++ {
+		rsig := cp.resSigs[i]
+		if rsig == nil || rsig.rPrime == nil || rsig.resS == nil {
+			return ErrIncorrectCredProof
+		}
+		if len(rsig.resT) != len(cp.resAttr[i])+1 || len(rsig.resT) > sp.MaxAttrs+1 {
+			return ErrIncorrectCredProof
+		}
+		for _, t := range rsig.resT {
+			if t == nil {
+				return ErrIncorrectCredProof
+			}
+		}
+		if i != level && cp.resUPK[i] == nil {
+			return ErrIncorrectCredProof
+		}
+		for j, a := range cp.resAttr[i] {
+			if a == nil && attrSet.Get(i, j) == nil {
+				return ErrIncorrectCredProof
+			}
+		}
+	}
+
+	return nil
+}
+
+// Marshal 将 CredProof 序列化为：
+//
+//	version || level || comm || proof_1 || ... || proof_level || resUSK || resNym || hasR || [resR1 || resR2]
+//
+// 其中proof_i = rPrime || resS || len(resT) || resT || resAttr || [resUPK]，第i层的群元素按层级在G1与G2间交替；
+// resAttr中每个元素前有1字节标记其是否存在（被披露的属性不存在），resUPK仅在i < level时存在
+func (cp *CredProof) Marshal() ([]byte, error) {
+	const prefix = "failed to marshal credential proof"
+	if cp.comm == nil || cp.resUSK == nil || cp.resNym == nil || len(cp.resSigs) == 0 {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrIncorrectCredProof)
+	}
+	level := len(cp.resSigs) - 1
+	if len(cp.resAttr) != level+1 || len(cp.resUPK) != level+1 {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrIncorrectCredProof)
+	}
+	buff := []byte{credProofEncodingV1}
+	buff = binary.BigEndian.AppendUint32(buff, uint32(level))
+	buff = utils.AppendScalar(buff, cp.comm)
+
+	var err error
+	appendElem := func(a any) {
+		if err == nil {
+			buff, err = utils.AppendElem(buff, a)
+		}
+	}
+	for i := 1; i <= level; i++ {
+		rsig := cp.resSigs[i]
+		if rsig == nil || len(rsig.resT) != len(cp.resAttr[i])+1 {
+			return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, ErrIncorrectCredProof)
+		}
+		appendElem(rsig.rPrime)
+		appendElem(rsig.resS)
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(rsig.resT)))
+		for _, t := range rsig.resT {
+			appendElem(t)
+		}
+		for _, a := range cp.resAttr[i] {
+			buff = utils.AppendBool(buff, a != nil)
+			if a != nil {
+				appendElem(a)
+			}
+		}
+		if i != level {
+			appendElem(cp.resUPK[i])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+		}
+	}
+	buff = utils.AppendScalar(buff, cp.resUSK)
+	buff = utils.AppendScalar(buff, cp.resNym)
+	if (cp.resR1 == nil) != (cp.resR2 == nil) {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrIncorrectCredProof)
+	}
+	buff = utils.AppendBool(buff, cp.resR1 != nil)
+	if cp.resR1 != nil {
+		buff = utils.AppendScalar(buff, cp.resR1)
+		buff = utils.AppendScalar(buff, cp.resR2)
+	}
+
+	return buff, nil
+}
+
+// Unmarshal 反序列化由 CredProof.Marshal 产生的buff，所有群元素须是合法的点且位于对应层级的群中，
+// 所有标量须小于bn256.Order
+func (cp *CredProof) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal credential proof"
+	d := utils.NewDecoder(buff)
+	version, err := d.ReadByte()
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if version != credProofEncodingV1 {
+		return fmt.Errorf("%s: %w %d", prefix, ErrUnknownProofVer, version)
+	}
+	// 每层证明至少包含rPrime、resS与resT，据此限制level以避免超大的内存分配
+	level, err := d.ReadCount(3 * utils.G1SizeByte)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if level == 0 {
+		return fmt.Errorf("%s: %w, level must be greater than 0", prefix, ErrMalformedCredProof)
+	}
+	res := &CredProof{
+		resSigs: make([]*resSig, level+1),
+		resAttr: make([][]any, level+1),
+		resUPK:  make([]any, level+1),
+	}
+	if res.comm, err = d.ReadScalar(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	for i := 1; i <= level; i++ {
+		inG1 := i%2 == 0
+		rsig := new(resSig)
+		if rsig.rPrime, err = d.ReadElem(!inG1); err != nil {
+			return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+		}
+		if rsig.resS, err = d.ReadElem(inG1); err != nil {
+			return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+		}
+		nT, err := d.ReadCount(utils.G1SizeByte)
+		if err != nil {
+			return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+		}
+		if nT == 0 {
+			return fmt.Errorf("%s at level-%d: %w, empty resT", prefix, i, ErrMalformedCredProof)
+		}
+		rsig.resT = make([]any, nT)
+		for j := range rsig.resT {
+			if rsig.resT[j], err = d.ReadElem(inG1); err != nil {
+				return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+			}
+		}
+		res.resAttr[i] = make([]any, nT-1)
+		for j := range res.resAttr[i] {
+			present, err := d.ReadBool()
+			if err != nil {
+				return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+			}
+			if !present {
+				continue
+			}
+			if res.resAttr[i][j], err = d.ReadElem(inG1); err != nil {
+				return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+			}
+		}
+		if i != level {
+			if res.resUPK[i], err = d.ReadElem(inG1); err != nil {
+				return fmt.Errorf("%s at level-%d: %w", prefix, i, err)
+			}
+		}
+		res.resSigs[i] = rsig
+	}
+
+	if res.resUSK, err = d.ReadScalar(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.resNym, err = d.ReadScalar(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	hasR, err := d.ReadBool()
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if hasR {
+		if res.resR1, err = d.ReadScalar(); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+		if res.resR2, err = d.ReadScalar(); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	*cp = *res
+
+	return nil
+}
+
+// hashCredComm returns the Fiat-Shamir challenge HASH(rootUPK, rPrimes, cijs, cnym, ca, attrSet, m) mod bn256.Order,
+// so that it can be serialized as a fixed-width scalar.
+func hashCredComm(
+	rootUPK *PK, rPrimes []any, cijs [][]*bn.GT, cnym serializable, ca *credAudit, attrSet AttrSet, m []byte,
+) *big.Int {
 	h := sha256.New()
 	h.Write(rootUPK.Marshal())
 	for _, v := range rPrimes {
@@ -298,10 +619,17 @@ func hashCredComm(rootUPK *PK, rPrimes []any, cijs [][]*bn.GT, cnym serializable
 	}
 	cnym2, _ := utils.Copy(cnym)
 	h.Write(cnym2.(serializable).Marshal())
+	if ca != nil {
+		h.Write(ca.com1.(serializable).Marshal())
+		h.Write(ca.com2.(serializable).Marshal())
+		h.Write(ca.com3.(serializable).Marshal())
+		h.Write(ca.cttbe.Marshal())
+	}
 	h.Write(attrSet.Marshal())
 	h.Write(m)
+	c := new(big.Int).SetBytes(h.Sum(nil))
 
-	return h.Sum(nil)
+	return c.Mod(c, bn.Order)
 }
 
 func compactCijs(cijs [][]*bn.GT) []*bn.GT {
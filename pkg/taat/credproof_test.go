@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/TomCN0803/taat-lib/pkg/groth"
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	"github.com/TomCN0803/taat-lib/pkg/ttbe"
 	bn "github.com/cloudflare/bn256"
 	"github.com/stretchr/testify/require"
 )
@@ -82,3 +84,259 @@ func randAttrSet(attrs [][]*Attribute, level, maxAttrs int) AttrSet {
 	}
 	return as
 }
+
+func TestAuditableCredProof(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		level      int
+		otherUPK   bool
+		plainCheck bool
+		err        error
+	}{
+		{
+			"happy path at level 1",
+			1,
+			false,
+			false,
+			nil,
+		},
+		{
+			"happy path at level 2",
+			2,
+			false,
+			false,
+			nil,
+		},
+		{
+			"happy path at level 3",
+			3,
+			false,
+			false,
+			nil,
+		},
+		{
+			"cttbe encrypts another upk",
+			2,
+			true,
+			false,
+			ErrIncorrectCredProof,
+		},
+		{
+			"verify auditable proof as plain proof",
+			1,
+			false,
+			true,
+			ErrIncorrectCredProof,
+		},
+	}
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			creds, usks := newTestCredChain(t, sp, secrets.RootUSK, tc.level)
+			cred, usk := creds[tc.level], usks[tc.level]
+			nymSK, nymPK, err := NewNymKeyPair(usk, hOfPK(sp, cred.upk))
+			require.NoError(t, err)
+			tag, err := rand.Int(rand.Reader, bn.Order)
+			require.NoError(t, err)
+			nonce := []byte("nonce")
+
+			upk := cred.upk
+			if tc.otherUPK {
+				_, upk = NewUserKeyPair(tc.level)
+			}
+			cttbe, r1, r2, err := ttbe.Encrypt(sp.TPK, tag, upk.pk)
+			require.NoError(t, err)
+
+			proof, err := NewAuditableCredProof(sp, cred, usk, nymSK, nil, cttbe, r1, r2, nonce)
+			require.NoError(t, err)
+			if tc.plainCheck {
+				err = proof.Verify(sp, nil, nymPK, nonce)
+			} else {
+				err = proof.VerifyAuditable(sp, nil, nymPK, cttbe, nonce)
+			}
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestAuditableCredProofMixedRandomness 中的密文C3、C6使用r1+r2，而C1、C2(及C4、C5)使用另一组随机数，
+// 该密文能通过 ttbe.IsValidEnc 但无法被审计者正确解密，证明必须被拒绝
+func TestAuditableCredProofMixedRandomness(t *testing.T) {
+	t.Parallel()
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+	creds, usks := newTestCredChain(t, sp, secrets.RootUSK, 2)
+	cred, usk := creds[2], usks[2]
+	nymSK, nymPK, err := NewNymKeyPair(usk, hOfPK(sp, cred.upk))
+	require.NoError(t, err)
+	tag, err := rand.Int(rand.Reader, bn.Order)
+	require.NoError(t, err)
+	nonce := []byte("nonce")
+
+	cttbe, r1, r2, err := ttbe.Encrypt(sp.TPK, tag, cred.upk.pk)
+	require.NoError(t, err)
+	other, _, _, err := ttbe.Encrypt(sp.TPK, tag, cred.upk.pk)
+	require.NoError(t, err)
+	mixed := &ttbe.Cttbe{
+		InG1: cttbe.InG1,
+		C1:   other.C1,
+		C2:   other.C2,
+		C3:   cttbe.C3,
+		C4:   other.C4,
+		C5:   other.C5,
+		C6:   cttbe.C6,
+	}
+	require.True(t, ttbe.IsValidEnc(sp.TPK, tag, mixed))
+
+	proof, err := NewAuditableCredProof(sp, cred, usk, nymSK, nil, mixed, r1, r2, nonce)
+	require.NoError(t, err)
+	require.ErrorIs(t, proof.VerifyAuditable(sp, nil, nymPK, mixed, nonce), ErrIncorrectCredProof)
+}
+
+func TestCredProofMarshal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		level     int
+		auditable bool
+	}{
+		{"level 1", 1, false},
+		{"level 2", 2, false},
+		{"level 3", 3, false},
+		{"auditable at level 2", 2, true},
+	}
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			creds, usks := newTestCredChain(t, sp, secrets.RootUSK, tc.level)
+			cred, usk := creds[tc.level], usks[tc.level]
+			nymSK, nymPK, err := NewNymKeyPair(usk, hOfPK(sp, cred.upk))
+			require.NoError(t, err)
+			// 披露部分属性，使resAttr中存在空位
+			attrSet, err := NewAttrSet(sp).
+				Disclose(1, 1, creds[1].attrs[1]).
+				Disclose(tc.level, 0, cred.attrs[0]).
+				Build()
+			require.NoError(t, err)
+			nonce := []byte("nonce")
+
+			var proof *CredProof
+			var cttbe *ttbe.Cttbe
+			if tc.auditable {
+				tag := big.NewInt(42)
+				var r1, r2 *big.Int
+				cttbe, r1, r2, err = ttbe.Encrypt(sp.TPK, tag, cred.upk.pk)
+				require.NoError(t, err)
+				proof, err = NewAuditableCredProof(sp, cred, usk, nymSK, attrSet, cttbe, r1, r2, nonce)
+			} else {
+				proof, err = NewCredProof(sp, cred, usk, nymSK, attrSet, nonce)
+			}
+			require.NoError(t, err)
+
+			buff, err := proof.Marshal()
+			require.NoError(t, err)
+			proof2 := new(CredProof)
+			require.NoError(t, proof2.Unmarshal(buff))
+			buff2, err := proof2.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, buff, buff2)
+
+			if tc.auditable {
+				require.NoError(t, proof2.VerifyAuditable(sp, attrSet, nymPK, cttbe, nonce))
+			} else {
+				require.NoError(t, proof2.Verify(sp, attrSet, nymPK, nonce))
+			}
+		})
+	}
+}
+
+func TestCredProofUnmarshalMalformed(t *testing.T) {
+	t.Parallel()
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+	creds, usks := newTestCredChain(t, sp, secrets.RootUSK, 2)
+	nymSK, _, err := NewNymKeyPair(usks[2], sp.H1)
+	require.NoError(t, err)
+	proof, err := NewCredProof(sp, creds[2], usks[2], nymSK, nil, []byte("nonce"))
+	require.NoError(t, err)
+	buff, err := proof.Marshal()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		tamper func(b []byte) []byte
+		err    error
+	}{
+		{
+			"empty",
+			func(b []byte) []byte { return nil },
+			utils.ErrShortBuffer,
+		},
+		{
+			"unknown version",
+			func(b []byte) []byte { b[0] = 2; return b },
+			ErrUnknownProofVer,
+		},
+		{
+			"zero level",
+			func(b []byte) []byte { copy(b[1:5], []byte{0, 0, 0, 0}); return b },
+			ErrMalformedCredProof,
+		},
+		{
+			"huge level",
+			func(b []byte) []byte { b[1] = 0xff; return b },
+			utils.ErrShortBuffer,
+		},
+		{
+			"challenge out of range",
+			func(b []byte) []byte {
+				copy(b[5:5+utils.ScalarSizeByte], bn.Order.FillBytes(make([]byte, utils.ScalarSizeByte)))
+				return b
+			},
+			utils.ErrScalarRange,
+		},
+		{
+			"rPrime off curve",
+			func(b []byte) []byte { b[5+utils.ScalarSizeByte+utils.G1SizeByte-1] ^= 1; return b },
+			utils.ErrMalformedPoint,
+		},
+		{
+			"truncated",
+			func(b []byte) []byte { return b[:len(b)-1] },
+			utils.ErrShortBuffer,
+		},
+		{
+			"trailing bytes",
+			func(b []byte) []byte { return append(b, 0) },
+			utils.ErrTrailingBytes,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			b := tc.tamper(append([]byte{}, buff...))
+			require.ErrorIs(t, new(CredProof).Unmarshal(b), tc.err)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package taat
 
 import (
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 	bn "github.com/cloudflare/bn256"
 )
 
@@ -16,7 +17,7 @@ type eComputer struct {
 }
 
 type eComArg struct {
-	args []*eArg
+	args []*utils.EArg
 	i, j int
 }
 
@@ -45,7 +46,7 @@ func (ec *eComputer) run() {
 	for i := 0; i < ec.nworker; i++ {
 		go func() {
 			for eca := range ec.inc {
-				r := eProduct(eca.args, eProdOptMillerLoopUnroll)
+				r := utils.EProduct(eca.args, utils.EProdOptMillerLoopUnroll)
 				ec.resc <- &eProdRes{r, eca.i, eca.j}
 			}
 			ec.resq <- struct{}{}
@@ -67,7 +68,7 @@ func (ec *eComputer) run() {
 	}()
 }
 
-func (ec *eComputer) enqueue(args []*eArg, i, j int) {
+func (ec *eComputer) enqueue(args []*utils.EArg, i, j int) {
 	ec.inc <- &eComArg{args, i, j}
 }
 
@@ -1,6 +1,9 @@
 package taat
 
-import bn "github.com/cloudflare/bn256"
+import (
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+)
 
 type eComputerSync struct {
 	nworker int
@@ -21,8 +24,8 @@ func newEComputerSync(nworker, rows, cols int) *eComputerSync {
 func (ec *eComputerSync) run() {
 }
 
-func (ec *eComputerSync) enqueue(args []*eArg, i, j int) {
-	ec.res[i][j] = eProduct(args, eProdOptMillerLoopUnroll)
+func (ec *eComputerSync) enqueue(args []*utils.EArg, i, j int) {
+	ec.res[i][j] = utils.EProduct(args, utils.EProdOptMillerLoopUnroll)
 }
 
 func (ec *eComputerSync) result() [][]*bn.GT {
@@ -1,6 +1,7 @@
 package taat
 
 import (
+	"crypto/rand"
 	"testing"
 
 	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
@@ -67,10 +68,10 @@ func BenchmarkEComputerSync(b *testing.B) {
 	}
 }
 
-func randArgMat(rows, cols, nargs int) [][][]*eArg {
-	argMat := make([][][]*eArg, rows)
+func randArgMat(rows, cols, nargs int) [][][]*utils.EArg {
+	argMat := make([][][]*utils.EArg, rows)
 	for i := range argMat {
-		argMat[i] = make([][]*eArg, cols)
+		argMat[i] = make([][]*utils.EArg, cols)
 		for j := range argMat[i] {
 			argMat[i][j] = randEArgs(nargs)
 		}
@@ -96,3 +97,15 @@ func equals(a, b [][]*bn.GT) bool {
 
 	return true
 }
+
+func randEArgs(n int) []*utils.EArg {
+	res := make([]*utils.EArg, n)
+	for i := range res {
+		_, g1, _ := bn.RandomG1(rand.Reader)
+		_, g2, _ := bn.RandomG2(rand.Reader)
+		c, _ := rand.Int(rand.Reader, bn.Order)
+		res[i] = &utils.EArg{A: g1, B: g2, C: c}
+	}
+
+	return res
+}
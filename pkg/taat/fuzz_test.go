@@ -0,0 +1,149 @@
+package taat
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/TomCN0803/taat-lib/pkg/ttbe"
+)
+
+// fuzzUnmarshal 检查任意输入都不会使unmarshal panic，且成功反序列化的输入重新序列化后保持不变
+func fuzzUnmarshal(f *testing.F, unmarshal func([]byte) ([]byte, error), seeds ...[]byte) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Add([]byte{})
+	f.Add([]byte{1})
+	f.Fuzz(func(t *testing.T, buff []byte) {
+		res, err := unmarshal(buff)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(res, buff) {
+			t.Fatalf("re-marshaled result differs from input %x", buff)
+		}
+	})
+}
+
+// fuzzSeeds 产生一条第2层的证书链及相关证明，作为模糊测试的初始语料
+type fuzzSeeds struct {
+	cred      *Credential
+	credProof *CredProof
+	nymSig    *NymSignature
+	uskProof  *UskProof
+	audProof  *AuditProof
+}
+
+func newFuzzSeeds(f *testing.F) *fuzzSeeds {
+	sp, secrets, err := Setup(2, 3, 2)
+	if err != nil {
+		f.Fatal(err)
+	}
+	usk1, upk1 := NewUserKeyPair(1)
+	usk2, upk2 := NewUserKeyPair(2)
+	cred1, err := NewRootCredential(sp.RootUPK).Delegate(sp, secrets.RootUSK, upk1, randNAttrs(2))
+	if err != nil {
+		f.Fatal(err)
+	}
+	cred2, err := cred1.Delegate(sp, usk1, upk2, randNAttrs(2))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	nonce := []byte("nonce")
+	nymSK, nymPK, err := NewNymKeyPair(usk2, sp.H1)
+	if err != nil {
+		f.Fatal(err)
+	}
+	cttbe, r1, r2, err := ttbe.Encrypt(sp.TPK, big.NewInt(1), upk2.pk)
+	if err != nil {
+		f.Fatal(err)
+	}
+	attrSet := AttrSet{&AttrSetElem{1, 1, cred1.attrs[1]}}
+	seeds := &fuzzSeeds{cred: cred2}
+	if seeds.credProof, err = NewAuditableCredProof(sp, cred2, usk2, nymSK, attrSet, cttbe, r1, r2, nonce); err != nil {
+		f.Fatal(err)
+	}
+	if seeds.nymSig, err = NewNymSignature(usk2, nymSK, nymPK, sp.H1, nonce); err != nil {
+		f.Fatal(err)
+	}
+	if seeds.uskProof, err = cred2.ProvePossession(usk2, nonce); err != nil {
+		f.Fatal(err)
+	}
+	if seeds.audProof, err = NewAuditProof(sp.TPK, cttbe, r1, r2, usk2, nymSK, nymPK, sp.H1); err != nil {
+		f.Fatal(err)
+	}
+
+	return seeds
+}
+
+func FuzzPKUnmarshal(f *testing.F) {
+	_, upk1 := NewUserKeyPair(1)
+	_, upk2 := NewUserKeyPair(2)
+	fuzzUnmarshal(f, func(buff []byte) ([]byte, error) {
+		pk := new(PK)
+		if err := pk.Unmarshal(buff); err != nil {
+			return nil, err
+		}
+		return pk.Marshal(), nil
+	}, upk1.Marshal(), upk2.Marshal())
+}
+
+func FuzzCredentialUnmarshal(f *testing.F) {
+	seed, err := newFuzzSeeds(f).cred.Marshal()
+	if err != nil {
+		f.Fatal(err)
+	}
+	fuzzUnmarshal(f, func(buff []byte) ([]byte, error) {
+		cred := new(Credential)
+		if err := cred.Unmarshal(buff); err != nil {
+			return nil, err
+		}
+		return cred.Marshal()
+	}, seed)
+}
+
+func FuzzCredProofUnmarshal(f *testing.F) {
+	seed, err := newFuzzSeeds(f).credProof.Marshal()
+	if err != nil {
+		f.Fatal(err)
+	}
+	fuzzUnmarshal(f, func(buff []byte) ([]byte, error) {
+		cp := new(CredProof)
+		if err := cp.Unmarshal(buff); err != nil {
+			return nil, err
+		}
+		return cp.Marshal()
+	}, seed)
+}
+
+func FuzzNymSignatureUnmarshal(f *testing.F) {
+	fuzzUnmarshal(f, func(buff []byte) ([]byte, error) {
+		ns := new(NymSignature)
+		if err := ns.Unmarshal(buff); err != nil {
+			return nil, err
+		}
+		return ns.Marshal(), nil
+	}, newFuzzSeeds(f).nymSig.Marshal())
+}
+
+func FuzzUskProofUnmarshal(f *testing.F) {
+	fuzzUnmarshal(f, func(buff []byte) ([]byte, error) {
+		up := new(UskProof)
+		if err := up.Unmarshal(buff); err != nil {
+			return nil, err
+		}
+		return up.Marshal(), nil
+	}, newFuzzSeeds(f).uskProof.Marshal())
+}
+
+func FuzzAuditProofUnmarshal(f *testing.F) {
+	fuzzUnmarshal(f, func(buff []byte) ([]byte, error) {
+		ap := new(AuditProof)
+		if err := ap.Unmarshal(buff); err != nil {
+			return nil, err
+		}
+		return ap.Marshal(), nil
+	}, newFuzzSeeds(f).audProof.Marshal())
+}
@@ -27,14 +27,27 @@ type PK struct {
 // NewUserKeyPair 根据授权层级level来生成用户公私钥对
 func NewUserKeyPair(level int) (sk *big.Int, upk *PK) {
 	sk, gpk := groth.GenKeyPair(nil)
-	upk = new(PK)
-	upk.inG1 = level%2 == 0
+	return sk, NewUPK(level, gpk)
+}
+
+// NewUPK 取Groth公钥gpk中与授权层级level对应的部分作为upk，偶数层位于G1，奇数层位于G2，
+// 例如由 groth.GenThresholdKey 产生的门限公钥
+func NewUPK(level int, gpk *groth.PK) *PK {
+	upk := &PK{inG1: level%2 == 0}
 	if upk.inG1 {
 		upk.pk = gpk.G1()
 	} else {
 		upk.pk = gpk.G2()
 	}
-	return sk, upk
+	return upk
+}
+
+// grothPK 返回验证upk所签发的Groth签名时使用的公钥，另一个群中的部分为nil
+func (pk *PK) grothPK() *groth.PK {
+	if pk.inG1 {
+		return groth.NewGrothPK(pk.pk.(*bn.G1), nil)
+	}
+	return groth.NewGrothPK(nil, pk.pk.(*bn.G2))
 }
 
 // Verify 验证pk是否由sk生成
@@ -68,32 +81,38 @@ func (pk *PK) Marshal() []byte {
 	return res
 }
 
+// Unmarshal unmarshals buff produced by PK.Marshal, buff must contain exactly one valid point.
 func (pk *PK) Unmarshal(buff []byte) error {
-	if buff[0] == 1 {
-		pk.inG1 = true
-	} else if buff[0] == 0 {
-		pk.inG1 = false
-	} else {
-		return fmt.Errorf("failed to unmarshal buff: %w", ErrIllegalInG1Byte)
+	d := utils.NewDecoder(buff)
+	res, err := decodePK(d)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal buff: %w", err)
 	}
-
-	if pk.inG1 {
-		pk.pk = new(bn.G1)
-		_, err := pk.pk.(*bn.G1).Unmarshal(buff[1 : 1+utils.G1SizeByte])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-	} else {
-		pk.pk = new(bn.G2)
-		_, err := pk.pk.(*bn.G2).Unmarshal(buff[1 : 1+utils.G2SizeByte])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("failed to unmarshal buff: %w", err)
 	}
+	*pk = *res
 
 	return nil
 }
 
+// decodePK reads a PK written by PK.Marshal.
+func decodePK(d *utils.Decoder) (*PK, error) {
+	inG1, err := d.ReadBool()
+	if errors.Is(err, utils.ErrIllegalBool) {
+		return nil, ErrIllegalInG1Byte
+	}
+	if err != nil {
+		return nil, err
+	}
+	pk, err := d.ReadElem(inG1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PK{inG1, pk.(serializable)}, nil
+}
+
 // Equals checks if pk == pk2
 func (pk *PK) Equals(pk2 *PK) bool {
 	return bytes.Equal(pk.Marshal(), pk2.Marshal())
@@ -121,7 +140,7 @@ func NewUSKProof(usk *big.Int, upk *PK, nonce []byte) (*UskProof, error) {
 	}
 
 	proof := new(UskProof)
-	proof.c = new(big.Int).SetBytes(uskProveHash(com, upk, nonce))
+	proof.c = uskProveHash(com, upk, nonce)
 	proof.p = utils.AddMod(r, utils.MulMod(proof.c, usk))
 
 	return proof, nil
@@ -136,19 +155,36 @@ func (up *UskProof) Verify(upk *PK, nonce []byte) error {
 		com = utils.ProductOfExpG2(utils.G2Generator(), up.p, upk.pk.(*bn.G2), cInv)
 	}
 
-	if !bytes.Equal(up.c.Bytes(), uskProveHash(com, upk, nonce)) {
+	if up.c.Cmp(uskProveHash(com, upk, nonce)) != 0 {
 		return ErrIncorrectUSKProof
 	}
 
 	return nil
 }
 
-// uskProveHash returns HASH(com, upk, nonce)
-func uskProveHash(com serializable, upk *PK, nonce []byte) []byte {
+// Marshal marshals UskProof as c || p, each a fixed-width scalar.
+func (up *UskProof) Marshal() []byte {
+	return marshalScalars(up.c, up.p)
+}
+
+// Unmarshal unmarshals buff produced by UskProof.Marshal, every scalar must be less than bn256.Order.
+func (up *UskProof) Unmarshal(buff []byte) error {
+	ks, err := unmarshalScalars(buff, 2)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal usk proof: %w", err)
+	}
+	up.c, up.p = ks[0], ks[1]
+
+	return nil
+}
+
+// uskProveHash returns HASH(com, upk, nonce) mod bn256.Order.
+func uskProveHash(com serializable, upk *PK, nonce []byte) *big.Int {
 	h := sha256.New()
 	h.Write(com.Marshal())
 	h.Write(upk.Marshal())
 	h.Write(nonce)
+	c := new(big.Int).SetBytes(h.Sum(nil))
 
-	return h.Sum(nil)
+	return c.Mod(c, bn.Order)
 }
@@ -53,6 +53,47 @@ func TestNymPKSerialize(t *testing.T) {
 	}
 }
 
+func TestPKUnmarshalMalformed(t *testing.T) {
+	t.Parallel()
+
+	_, upk := NewUserKeyPair(1)
+	buff := upk.Marshal()
+	testCases := []struct {
+		name string
+		buff []byte
+		err  error
+	}{
+		{
+			"empty",
+			nil,
+			utils.ErrShortBuffer,
+		},
+		{
+			"illegal inG1 byte",
+			append([]byte{2}, buff[1:]...),
+			ErrIllegalInG1Byte,
+		},
+		{
+			"truncated",
+			buff[:len(buff)-1],
+			utils.ErrShortBuffer,
+		},
+		{
+			"trailing bytes",
+			append(append([]byte{}, buff...), 0),
+			utils.ErrTrailingBytes,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorIs(t, new(PK).Unmarshal(tc.buff), tc.err)
+		})
+	}
+}
+
 func TestUskProof(t *testing.T) {
 	t.Parallel()
 
@@ -118,3 +159,23 @@ func TestUskProof(t *testing.T) {
 		})
 	}
 }
+
+func TestUskProofMarshal(t *testing.T) {
+	t.Parallel()
+
+	nonce := []byte("nonce")
+	usk, upk := NewUserKeyPair(1)
+	proof, err := NewUSKProof(usk, upk, nonce)
+	require.NoError(t, err)
+	buff := proof.Marshal()
+	require.Len(t, buff, 2*utils.ScalarSizeByte)
+
+	proof2 := new(UskProof)
+	require.NoError(t, proof2.Unmarshal(buff))
+	require.NoError(t, proof2.Verify(upk, nonce))
+
+	require.ErrorIs(t, new(UskProof).Unmarshal(buff[:len(buff)-1]), utils.ErrShortBuffer)
+	require.ErrorIs(t, new(UskProof).Unmarshal(append(buff, 0)), utils.ErrTrailingBytes)
+	outOfRange := append(bn.Order.FillBytes(make([]byte, utils.ScalarSizeByte)), buff[utils.ScalarSizeByte:]...)
+	require.ErrorIs(t, new(UskProof).Unmarshal(outOfRange), utils.ErrScalarRange)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"math/big"
 
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 	"github.com/cloudflare/bn256"
 )
 
@@ -23,3 +24,31 @@ func genKRandomBigInts(k int) ([]*big.Int, error) {
 
 	return res, nil
 }
+
+// marshalScalars 将ks依次编码为定长的 utils.ScalarSizeByte 字节
+func marshalScalars(ks ...*big.Int) []byte {
+	buff := make([]byte, 0, len(ks)*utils.ScalarSizeByte)
+	for _, k := range ks {
+		buff = utils.AppendScalar(buff, k)
+	}
+
+	return buff
+}
+
+// unmarshalScalars 从buff中解码恰好n个小于bn256.Order的标量
+func unmarshalScalars(buff []byte, n int) ([]*big.Int, error) {
+	d := utils.NewDecoder(buff)
+	ks := make([]*big.Int, n)
+	for i := range ks {
+		k, err := d.ReadScalar()
+		if err != nil {
+			return nil, err
+		}
+		ks[i] = k
+	}
+	if err := d.Finish(); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
@@ -1,7 +1,6 @@
 package taat
 
 import (
-	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
 	"errors"
@@ -62,7 +61,7 @@ func NewNymSignature(usk, nymSK *big.Int, nymPK *PK, h any, msg []byte) (*NymSig
 		return nil, fmt.Errorf("\"failed to generate new pseudonym signature\": %w", ErrWrongHType)
 	}
 
-	c := new(big.Int).SetBytes(nymSigProveHash(com, nymPK, msg))
+	c := nymSigProveHash(com, nymPK, msg)
 	ns := &NymSignature{c: c}
 	ns.pUSK = utils.AddMod(r1, utils.MulMod(c, usk))
 	ns.pNymSK = utils.AddMod(r2, utils.MulMod(c, nymSK))
@@ -91,19 +90,36 @@ func (ns *NymSignature) Verify(nymPK *PK, h any, msg []byte) error {
 		return fmt.Errorf("failed to verify pseudonym signature: %w", ErrWrongHType)
 	}
 
-	if !bytes.Equal(ns.c.Bytes(), nymSigProveHash(com, nymPK, msg)) {
+	if ns.c.Cmp(nymSigProveHash(com, nymPK, msg)) != 0 {
 		return fmt.Errorf("failed to verify pseudonym signature: %w", ErrIncorrectNymSig)
 	}
 
 	return nil
 }
 
-// nymSigProveHash returns HASH(com, nymPK, msg).
-func nymSigProveHash(com serializable, nymPK *PK, msg []byte) []byte {
+// Marshal marshals NymSignature as c || pUSK || pNymSK, each a fixed-width scalar.
+func (ns *NymSignature) Marshal() []byte {
+	return marshalScalars(ns.c, ns.pUSK, ns.pNymSK)
+}
+
+// Unmarshal unmarshals buff produced by NymSignature.Marshal, every scalar must be less than bn256.Order.
+func (ns *NymSignature) Unmarshal(buff []byte) error {
+	ks, err := unmarshalScalars(buff, 3)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal pseudonym signature: %w", err)
+	}
+	ns.c, ns.pUSK, ns.pNymSK = ks[0], ks[1], ks[2]
+
+	return nil
+}
+
+// nymSigProveHash returns HASH(com, nymPK, msg) mod bn256.Order.
+func nymSigProveHash(com serializable, nymPK *PK, msg []byte) *big.Int {
 	h := sha256.New()
 	h.Write(com.Marshal())
 	h.Write(nymPK.Marshal())
 	h.Write(msg)
+	c := new(big.Int).SetBytes(h.Sum(nil))
 
-	return h.Sum(nil)
+	return c.Mod(c, bn.Order)
 }
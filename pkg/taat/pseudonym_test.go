@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"testing"
 
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 	bn "github.com/cloudflare/bn256"
 	"github.com/stretchr/testify/require"
 )
@@ -110,3 +111,29 @@ func TestNewNymKeyPairWrongHType(t *testing.T) {
 	require.Nil(t, nymSK)
 	require.Nil(t, nymPK)
 }
+
+func TestNymSignatureMarshal(t *testing.T) {
+	t.Parallel()
+
+	usk, err := rand.Int(rand.Reader, bn.Order)
+	require.NoError(t, err)
+	_, h, err := bn.RandomG2(rand.Reader)
+	require.NoError(t, err)
+	nymSK, nymPK, err := NewNymKeyPair(usk, h)
+	require.NoError(t, err)
+	msg := []byte("msg")
+	nymSig, err := NewNymSignature(usk, nymSK, nymPK, h, msg)
+	require.NoError(t, err)
+	buff := nymSig.Marshal()
+	require.Len(t, buff, 3*utils.ScalarSizeByte)
+
+	nymSig2 := new(NymSignature)
+	require.NoError(t, nymSig2.Unmarshal(buff))
+	require.NoError(t, nymSig2.Verify(nymPK, h, msg))
+
+	require.ErrorIs(t, new(NymSignature).Unmarshal(buff[:len(buff)-1]), utils.ErrShortBuffer)
+	require.ErrorIs(t, new(NymSignature).Unmarshal(append(buff, 0)), utils.ErrTrailingBytes)
+	outOfRange := append([]byte{}, buff...)
+	copy(outOfRange[utils.ScalarSizeByte:], bn.Order.FillBytes(make([]byte, utils.ScalarSizeByte)))
+	require.ErrorIs(t, new(NymSignature).Unmarshal(outOfRange), utils.ErrScalarRange)
+}
@@ -1,11 +1,24 @@
 package taat
 
 import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
 	"github.com/TomCN0803/taat-lib/pkg/groth"
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 	"github.com/TomCN0803/taat-lib/pkg/ttbe"
 	bn "github.com/cloudflare/bn256"
 )
 
+var (
+	ErrIllegalMaxAttrs    = errors.New("illegal max attribute number, must greater than 0")
+	ErrIllegalThreshold   = errors.New("illegal threshold, must be in [1, number of auditors]")
+	ErrIncompleteParams   = errors.New("incomplete parameters")
+	ErrInconsistentParams = errors.New("inconsistent parameters")
+)
+
 // Parameters TAAT公共参数
 type Parameters struct {
 	H1 *bn.G1
@@ -13,7 +26,105 @@ type Parameters struct {
 
 	MaxAttrs int // 最大 Attribute 数量
 
-	TPK     *ttbe.TPK         // TTBE公钥
-	Groth   *groth.Parameters // Groth签名公共参数
-	RootUPK *PK               // 根Authority的公钥
+	TPK       *ttbe.TPK         // TTBE公钥
+	TVKs      []*ttbe.TVK       // 审计者的TTBE验证密钥
+	Threshold uint64            // 审计门限，合并审计线索至少需要Threshold个审计者
+	Groth     *groth.Parameters // Groth签名公共参数
+	RootUPK   *PK               // 根Authority的公钥
+}
+
+// Secrets TAAT初始化时产生的私密参数，需要分发给对应的持有者，不能公开
+type Secrets struct {
+	RootUSK *big.Int    // 根Authority的私钥
+	TSKs    []*ttbe.TSK // 审计者的TTBE私钥，TSKs[i]对应TVKs[i]
+}
+
+// Setup 初始化TAAT参数，maxAttrs为每层 Credential 的最大 Attribute 数量，
+// nAuditors为审计者的数量，threshold为审计的门限阈值
+func Setup(maxAttrs int, nAuditors, threshold uint64) (*Parameters, *Secrets, error) {
+	const prefix = "failed to set up taat"
+	if maxAttrs <= 0 {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, ErrIllegalMaxAttrs)
+	}
+	if threshold == 0 || threshold > nAuditors {
+		return nil, nil, fmt.Errorf("%s: %w, got %d of %d", prefix, ErrIllegalThreshold, threshold, nAuditors)
+	}
+
+	// Groth消息由upk与attrs组成，因此Y1s与Y2s的长度为maxAttrs+1
+	gsp, err := groth.Setup(maxAttrs+1, maxAttrs+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	tsp, err := ttbe.Setup(nAuditors, threshold)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	h, err := rand.Int(rand.Reader, bn.Order)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	rootUSK, rootUPK := NewUserKeyPair(0)
+
+	sp := &Parameters{
+		H1:        utils.NewG1(h),
+		H2:        utils.NewG2(h),
+		MaxAttrs:  maxAttrs,
+		TPK:       tsp.TPK,
+		TVKs:      tsp.TVKs,
+		Threshold: threshold,
+		Groth:     gsp,
+		RootUPK:   rootUPK,
+	}
+	if err = sp.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	return sp, &Secrets{rootUSK, tsp.TSKs}, nil
+}
+
+// Validate 检查公共参数各组成部分之间是否一致：
+//  1. 各参数均已设置
+//  2. Groth参数的Y1s、Y2s长度均为MaxAttrs+1
+//  3. 根公钥位于G1（第0层）
+//  4. H1与H2具有相同的离散对数
+//  5. TVKs不少于审计门限Threshold且不含nil，审计者id非0且互不相同
+func (sp *Parameters) Validate() error {
+	const prefix = "invalid taat parameters"
+	if sp.H1 == nil || sp.H2 == nil || sp.TPK == nil || sp.Groth == nil || sp.RootUPK == nil {
+		return fmt.Errorf("%s: %w", prefix, ErrIncompleteParams)
+	}
+	if sp.MaxAttrs <= 0 {
+		return fmt.Errorf("%s: %w", prefix, ErrIllegalMaxAttrs)
+	}
+	if len(sp.Groth.Y1s) != sp.MaxAttrs+1 || len(sp.Groth.Y2s) != sp.MaxAttrs+1 {
+		return fmt.Errorf(
+			"%s: %w, groth Y1s(%d) and Y2s(%d) must both be MaxAttrs+1(%d)",
+			prefix,
+			ErrInconsistentParams,
+			len(sp.Groth.Y1s),
+			len(sp.Groth.Y2s),
+			sp.MaxAttrs+1,
+		)
+	}
+	if !sp.RootUPK.inG1 {
+		return fmt.Errorf("%s: %w, root upk must be in G1", prefix, ErrInconsistentParams)
+	}
+	if sp.Threshold == 0 || uint64(len(sp.TVKs)) < sp.Threshold {
+		return fmt.Errorf("%s: %w, got %d of %d", prefix, ErrIllegalThreshold, sp.Threshold, len(sp.TVKs))
+	}
+	ids := make(map[uint64]bool, len(sp.TVKs))
+	for i, tvk := range sp.TVKs {
+		if tvk == nil {
+			return fmt.Errorf("%s: %w, nil tvk at index %d", prefix, ErrIncompleteParams, i)
+		}
+		if tvk.ID() == 0 || ids[tvk.ID()] {
+			return fmt.Errorf("%s: %w, zero or duplicate auditor id %d", prefix, ErrInconsistentParams, tvk.ID())
+		}
+		ids[tvk.ID()] = true
+	}
+	if !utils.Equals(bn.Pair(sp.H1, utils.G2Generator()), bn.Pair(utils.G1Generator(), sp.H2)) {
+		return fmt.Errorf("%s: %w, H1 and H2 must share the same exponent", prefix, ErrInconsistentParams)
+	}
+
+	return nil
 }
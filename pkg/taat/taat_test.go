@@ -0,0 +1,150 @@
+package taat
+
+import (
+	"testing"
+
+	"github.com/TomCN0803/taat-lib/pkg/groth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		maxAttrs  int
+		nAuditors uint64
+		threshold uint64
+		err       error
+	}{
+		{
+			"happy path",
+			5,
+			5,
+			3,
+			nil,
+		},
+		{
+			"illegal max attrs",
+			0,
+			5,
+			3,
+			ErrIllegalMaxAttrs,
+		},
+		{
+			"zero threshold",
+			5,
+			5,
+			0,
+			ErrIllegalThreshold,
+		},
+		{
+			"threshold larger than auditors",
+			5,
+			3,
+			5,
+			ErrIllegalThreshold,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			sp, secrets, err := Setup(tc.maxAttrs, tc.nAuditors, tc.threshold)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				require.Nil(t, sp)
+				require.Nil(t, secrets)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, sp.Validate())
+			require.Len(t, sp.TVKs, int(tc.nAuditors))
+			require.Equal(t, tc.threshold, sp.Threshold)
+			require.Len(t, secrets.TSKs, int(tc.nAuditors))
+			require.True(t, sp.RootUPK.Verify(secrets.RootUSK))
+
+			// 使用Setup产生的参数进行证书授权
+			usk, upk := NewUserKeyPair(1)
+			cred, err := NewRootCredential(sp.RootUPK).Delegate(sp, secrets.RootUSK, upk, randNAttrs(sp.MaxAttrs))
+			require.NoError(t, err)
+			require.NoError(t, cred.Verify(sp, 1, usk, sp.RootUPK))
+		})
+	}
+}
+
+func TestParametersValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		mutate func(sp *Parameters)
+		err    error
+	}{
+		{
+			"missing TPK",
+			func(sp *Parameters) { sp.TPK = nil },
+			ErrIncompleteParams,
+		},
+		{
+			"illegal max attrs",
+			func(sp *Parameters) { sp.MaxAttrs = 0 },
+			ErrIllegalMaxAttrs,
+		},
+		{
+			"max attrs out of sync with groth",
+			func(sp *Parameters) { sp.MaxAttrs++ },
+			ErrInconsistentParams,
+		},
+		{
+			"groth Y1s and Y2s of different length",
+			func(sp *Parameters) {
+				gsp, _ := groth.Setup(sp.MaxAttrs+1, sp.MaxAttrs+2)
+				sp.Groth = gsp
+			},
+			ErrInconsistentParams,
+		},
+		{
+			"root upk in G2",
+			func(sp *Parameters) { _, sp.RootUPK = NewUserKeyPair(1) },
+			ErrInconsistentParams,
+		},
+		{
+			"fewer tvks than the threshold",
+			func(sp *Parameters) { sp.TVKs = sp.TVKs[:1] },
+			ErrIllegalThreshold,
+		},
+		{
+			"zero threshold",
+			func(sp *Parameters) { sp.Threshold = 0 },
+			ErrIllegalThreshold,
+		},
+		{
+			"nil tvk",
+			func(sp *Parameters) { sp.TVKs[1] = nil },
+			ErrIncompleteParams,
+		},
+		{
+			"duplicate auditor id",
+			func(sp *Parameters) { sp.TVKs[2] = sp.TVKs[0] },
+			ErrInconsistentParams,
+		},
+		{
+			"H1 and H2 of different exponents",
+			func(sp *Parameters) { sp.H1.Add(sp.H1, sp.H1) },
+			ErrInconsistentParams,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			sp, _, err := Setup(3, 3, 2)
+			require.NoError(t, err)
+			tc.mutate(sp)
+			require.ErrorIs(t, sp.Validate(), tc.err)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package taat
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/TomCN0803/taat-lib/pkg/ttbe"
+	bn "github.com/cloudflare/bn256"
+)
+
+var ErrIncompleteTx = errors.New("incomplete transaction")
+
+const txNonceSize = 32 // Transaction 随机数的字节数
+
+// Transaction 门限可审计匿名交易，包括：
+//  1. NymPK：交易者的假名公钥，所有证明均针对同一个NymPK
+//  2. Nonce：交易随机数，与NymPK共同决定TTBE的tag
+//  3. Cttbe：在tag下对交易者upk的TTBE加密，审计者可据此恢复交易者身份
+//  4. CredProof：可审计的证书证明，证明交易者持有有效的 Credential 并披露Disclosed中的属性，
+//     同时证明Cttbe加密的正是该 Credential 的upk，且与NymPK来自同一个usk
+//  5. NymSig：交易者使用假名私钥对交易内容的签名
+type Transaction struct {
+	NymPK     *PK
+	Nonce     []byte
+	Disclosed AttrSet
+	Cttbe     *ttbe.Cttbe
+	CredProof *CredProof
+	NymSig    *NymSignature
+}
+
+// NewTransaction 使用 Credential cred 对交易内容payload产生新的 Transaction，
+// nymSK与nymPK须由usk通过 NewNymKeyPair 产生，且与cred的upk在同一个群中
+func NewTransaction(
+	sp *Parameters, cred *Credential, usk, nymSK *big.Int, nymPK *PK, disclosed AttrSet, payload []byte,
+) (*Transaction, error) {
+	const prefix = "failed to generate new transaction"
+	if !cred.upk.Verify(usk) {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrWrongUPK)
+	}
+	if nymPK.inG1 != cred.upk.inG1 {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrWrongGroupNymPK)
+	}
+
+	nonce := make([]byte, txNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	tx := &Transaction{
+		NymPK:     nymPK,
+		Nonce:     nonce,
+		Disclosed: disclosed,
+	}
+
+	cttbe, r1, r2, err := ttbe.Encrypt(sp.TPK, tx.Tag(), cred.upk.pk)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	tx.Cttbe = cttbe
+
+	digest := tx.digest(payload)
+	tx.CredProof, err = NewAuditableCredProof(sp, cred, usk, nymSK, disclosed, cttbe, r1, r2, digest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	tx.NymSig, err = NewNymSignature(usk, nymSK, nymPK, hOfPK(sp, nymPK), digest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	return tx, nil
+}
+
+// Verify 验证 Transaction 的有效性，所有组成部分都针对同一个NymPK、tag与nonce进行验证：
+//  1. Cttbe在tag下是有效的TTBE密文
+//  2. CredProof对Disclosed、Cttbe与交易摘要有效
+//  3. NymSig对交易摘要有效
+//
+// 缺少NymPK、Cttbe、CredProof或NymSig的交易返回 ErrIncompleteTx
+func (tx *Transaction) Verify(sp *Parameters, payload []byte) error {
+	const prefix = "failed to verify transaction"
+	if tx.NymPK == nil || tx.NymPK.pk == nil || tx.Cttbe == nil || tx.CredProof == nil || tx.NymSig == nil {
+		return fmt.Errorf("%s: %w", prefix, ErrIncompleteTx)
+	}
+	if tx.Cttbe.InG1 != tx.NymPK.inG1 {
+		return fmt.Errorf("%s: %w", prefix, ErrCttbeAndPKsNotInSameGroup)
+	}
+	if !ttbe.IsValidEnc(sp.TPK, tx.Tag(), tx.Cttbe) {
+		return fmt.Errorf("%s: %w", prefix, ttbe.ErrInvalidCttbe)
+	}
+
+	digest := tx.digest(payload)
+	if err := tx.CredProof.VerifyAuditable(sp, tx.Disclosed, tx.NymPK, tx.Cttbe, digest); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if err := tx.NymSig.Verify(tx.NymPK, hOfPK(sp, tx.NymPK), digest); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	return nil
+}
+
+// Tag 返回 Transaction 的TTBE标签，即HASH(NymPK, Nonce) mod bn256.Order，
+// 审计者使用该标签调用 ttbe.ShareAudClue 与 ttbe.Combine
+func (tx *Transaction) Tag() *big.Int {
+	h := sha256.New()
+	h.Write(tx.NymPK.Marshal())
+	h.Write(tx.Nonce)
+	tag := new(big.Int).SetBytes(h.Sum(nil))
+
+	return tag.Mod(tag, bn.Order)
+}
+
+// digest returns HASH(NymPK, Nonce, Cttbe, Disclosed, payload), which is the message
+// proved by CredProof and signed by NymSig.
+func (tx *Transaction) digest(payload []byte) []byte {
+	h := sha256.New()
+	h.Write(tx.NymPK.Marshal())
+	h.Write(tx.Nonce)
+	h.Write(tx.Cttbe.Marshal())
+	h.Write(tx.Disclosed.Marshal())
+	h.Write(payload)
+
+	return h.Sum(nil)
+}
+
+// hOfPK 返回与pk在同一个群中的H1或H2
+func hOfPK(sp *Parameters, pk *PK) any {
+	if pk.inG1 {
+		return sp.H1
+	}
+	return sp.H2
+}
@@ -0,0 +1,175 @@
+package taat
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/TomCN0803/taat-lib/pkg/ttbe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		level  int
+		tamper func(sp *Parameters, tx *Transaction, payload []byte) []byte
+		err    error
+	}{
+		{
+			"happy path at level 1",
+			1,
+			nil,
+			nil,
+		},
+		{
+			"happy path at level 2",
+			2,
+			nil,
+			nil,
+		},
+		{
+			"wrong payload",
+			2,
+			func(sp *Parameters, tx *Transaction, payload []byte) []byte { return []byte("another payload") },
+			ErrIncorrectCredProof,
+		},
+		{
+			"tampered nonce",
+			1,
+			func(sp *Parameters, tx *Transaction, payload []byte) []byte {
+				tx.Nonce[0] ^= 0xff
+				return payload
+			},
+			ttbe.ErrInvalidCttbe,
+		},
+		{
+			"cttbe replaced by encryption of another upk",
+			1,
+			func(sp *Parameters, tx *Transaction, payload []byte) []byte {
+				_, upk := NewUserKeyPair(1)
+				tx.Cttbe, _, _, _ = ttbe.Encrypt(sp.TPK, tx.Tag(), upk.pk)
+				return payload
+			},
+			ErrIncorrectCredProof,
+		},
+		{
+			"tampered disclosed attributes",
+			2,
+			func(sp *Parameters, tx *Transaction, payload []byte) []byte {
+				tx.Disclosed = tx.Disclosed[:0]
+				return payload
+			},
+			ErrIncorrectCredProof,
+		},
+		{
+			"missing cttbe",
+			1,
+			func(sp *Parameters, tx *Transaction, payload []byte) []byte {
+				tx.Cttbe = nil
+				return payload
+			},
+			ErrIncompleteTx,
+		},
+		{
+			"missing nym signature",
+			1,
+			func(sp *Parameters, tx *Transaction, payload []byte) []byte {
+				tx.NymSig = nil
+				return payload
+			},
+			ErrIncompleteTx,
+		},
+		{
+			"missing nym public key",
+			1,
+			func(sp *Parameters, tx *Transaction, payload []byte) []byte {
+				tx.NymPK = nil
+				return payload
+			},
+			ErrIncompleteTx,
+		},
+		{
+			"missing credential proof",
+			1,
+			func(sp *Parameters, tx *Transaction, payload []byte) []byte {
+				tx.CredProof = nil
+				return payload
+			},
+			ErrIncompleteTx,
+		},
+	}
+
+	sp, secrets, err := Setup(3, 5, 3)
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			creds, usks := newTestCredChain(t, sp, secrets.RootUSK, tc.level)
+			cred, usk := creds[tc.level], usks[tc.level]
+			nymSK, nymPK, err := NewNymKeyPair(usk, hOfPK(sp, cred.upk))
+			require.NoError(t, err)
+			disclosed := AttrSet{&AttrSetElem{tc.level, 0, cred.attrs[0]}}
+			payload := []byte("transfer 100 to Bob")
+
+			tx, err := NewTransaction(sp, cred, usk, nymSK, nymPK, disclosed, payload)
+			require.NoError(t, err)
+			if tc.tamper != nil {
+				payload = tc.tamper(sp, tx, payload)
+			}
+			err = tx.Verify(sp, payload)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			// 审计者使用交易的tag恢复交易者的upk
+			clues := make([]*ttbe.AudClue, 3)
+			for i := range clues {
+				clues[i], err = ttbe.ShareAudClue(sp.TPK, tx.Tag(), tx.Cttbe, secrets.TSKs[i])
+				require.NoError(t, err)
+			}
+			upk, err := ttbe.Combine(sp.TPK, tx.Tag(), tx.Cttbe, sp.TVKs[:3], clues)
+			require.NoError(t, err)
+			require.True(t, cred.upk.Equals(&PK{cred.upk.inG1, upk.(serializable)}))
+		})
+	}
+}
+
+func TestNewTransactionWrongKeys(t *testing.T) {
+	t.Parallel()
+
+	sp, secrets, err := Setup(3, 3, 2)
+	require.NoError(t, err)
+	creds, usks := newTestCredChain(t, sp, secrets.RootUSK, 1)
+
+	nymSK, nymPK, err := NewNymKeyPair(usks[1], sp.H2)
+	require.NoError(t, err)
+	_, err = NewTransaction(sp, creds[1], usks[0], nymSK, nymPK, nil, nil)
+	require.ErrorIs(t, err, ErrWrongUPK)
+
+	nymSK, nymPK, err = NewNymKeyPair(usks[1], sp.H1)
+	require.NoError(t, err)
+	_, err = NewTransaction(sp, creds[1], usks[1], nymSK, nymPK, nil, nil)
+	require.ErrorIs(t, err, ErrWrongGroupNymPK)
+}
+
+// newTestCredChain 产生从根到level层的证书链，返回各层的证书与私钥
+func newTestCredChain(t *testing.T, sp *Parameters, rootUSK *big.Int, level int) ([]*Credential, []*big.Int) {
+	creds := make([]*Credential, level+1)
+	usks := make([]*big.Int, level+1)
+	creds[0], usks[0] = NewRootCredential(sp.RootUPK), rootUSK
+	for i := 1; i <= level; i++ {
+		var upk *PK
+		var err error
+		usks[i], upk = NewUserKeyPair(i)
+		creds[i], err = creds[i-1].Delegate(sp, usks[i-1], upk, randNAttrs(sp.MaxAttrs))
+		require.NoError(t, err)
+	}
+
+	return creds, usks
+}
@@ -0,0 +1,138 @@
+package ttbe
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+)
+
+var ErrUnequalLenOfTagsAndCttbes = errors.New("unequal length of tags and cttbes")
+
+// batchCoeffBits 批量验证中随机系数的比特长度，伪造的密文通过验证的概率不超过2^-128
+const batchCoeffBits = 128
+
+// IsValidEncBatch 批量验证cttbes[i]是否在tpk和tags[i]下有效，全部有效时返回nil。
+// 对每个密文选取随机系数ρ_i、σ_i，将 IsValidEnc 的两个配对等式合并为
+//
+//	e(Σtag_i*(ρ_i*C1_i + σ_i*C2_i), U) * e(Σρ_i*C1_i, W) * e(Σσ_i*C2_i, Z) * e(-Σρ_i*C4_i, H) * e(-Σσ_i*C5_i, V) == 1
+//
+// G1、G2中的密文各得到一个这样的等式，所有配对共享同一次最终幂运算。
+// 批量验证不通过时逐个验证，并返回无效密文的下标
+func IsValidEncBatch(tpk *TPK, tags []*big.Int, cttbes []*Cttbe) ([]int, error) {
+	if len(tags) != len(cttbes) {
+		return nil, ErrUnequalLenOfTagsAndCttbes
+	}
+
+	accs := [2]*encBatchAcc{newEncBatchAcc(true), newEncBatchAcc(false)}
+	for i, c := range cttbes {
+		rho, err := randBatchCoeff()
+		if err != nil {
+			return nil, err
+		}
+		sigma, err := randBatchCoeff()
+		if err != nil {
+			return nil, err
+		}
+		acc := accs[0]
+		if !c.InG1 {
+			acc = accs[1]
+		}
+		if err = acc.add(tags[i], c, rho, sigma); err != nil {
+			return nil, err
+		}
+	}
+
+	var args []*utils.EArg
+	for _, acc := range accs {
+		if acc.n > 0 {
+			args = append(args, acc.eArgs(tpk)...)
+		}
+	}
+	if utils.IsEProductOne(args) {
+		return nil, nil
+	}
+
+	var failed []int
+	for i, c := range cttbes {
+		if !IsValidEnc(tpk, tags[i], c) {
+			failed = append(failed, i)
+		}
+	}
+
+	return failed, nil
+}
+
+// encBatchAcc 累加同一个群中的密文在批量验证等式中各配对的左侧元素
+type encBatchAcc struct {
+	inG1          bool
+	n             int
+	u, w, z, h, v any
+}
+
+func newEncBatchAcc(inG1 bool) *encBatchAcc {
+	zero := big.NewInt(0)
+	return &encBatchAcc{
+		inG1: inG1,
+		u:    utils.ScalarBaseMult(inG1, zero),
+		w:    utils.ScalarBaseMult(inG1, zero),
+		z:    utils.ScalarBaseMult(inG1, zero),
+		h:    utils.ScalarBaseMult(inG1, zero),
+		v:    utils.ScalarBaseMult(inG1, zero),
+	}
+}
+
+func (acc *encBatchAcc) add(tag *big.Int, c *Cttbe, rho, sigma *big.Int) error {
+	terms := []struct {
+		dst  *any
+		elem any
+		k    *big.Int
+	}{
+		{&acc.u, c.C1, utils.MulMod(rho, tag)},
+		{&acc.u, c.C2, utils.MulMod(sigma, tag)},
+		{&acc.w, c.C1, rho},
+		{&acc.z, c.C2, sigma},
+		{&acc.h, c.C4, utils.AddInv(rho, bn.Order)},
+		{&acc.v, c.C5, utils.AddInv(sigma, bn.Order)},
+	}
+	for _, term := range terms {
+		e, err := utils.ScalarMult(term.elem, term.k)
+		if err != nil {
+			return err
+		}
+		if *term.dst, err = utils.Add(*term.dst, e); err != nil {
+			return err
+		}
+	}
+	acc.n++
+
+	return nil
+}
+
+// eArgs 返回累加结果与tpk中对应元素组成的配对项
+func (acc *encBatchAcc) eArgs(tpk *TPK) []*utils.EArg {
+	if acc.inG1 {
+		return []*utils.EArg{
+			utils.NewEArg(acc.u, tpk.U2, nil),
+			utils.NewEArg(acc.w, tpk.W2, nil),
+			utils.NewEArg(acc.z, tpk.Z2, nil),
+			utils.NewEArg(acc.h, tpk.H2, nil),
+			utils.NewEArg(acc.v, tpk.V2, nil),
+		}
+	}
+
+	return []*utils.EArg{
+		utils.NewEArg(tpk.U1, acc.u, nil),
+		utils.NewEArg(tpk.W1, acc.w, nil),
+		utils.NewEArg(tpk.Z1, acc.z, nil),
+		utils.NewEArg(tpk.H1, acc.h, nil),
+		utils.NewEArg(tpk.V1, acc.v, nil),
+	}
+}
+
+// randBatchCoeff 返回批量验证使用的batchCoeffBits比特随机系数
+func randBatchCoeff() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), batchCoeffBits))
+}
@@ -0,0 +1,104 @@
+package ttbe
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const numBatchCttbes = 32
+
+func BenchmarkIsValidEnc(b *testing.B) {
+	tpk, tags, cttbes := mockBatchCttbes(b, numBatchCttbes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, c := range cttbes {
+			IsValidEnc(tpk, tags[j], c)
+		}
+	}
+}
+
+func BenchmarkIsValidEncBatch(b *testing.B) {
+	tpk, tags, cttbes := mockBatchCttbes(b, numBatchCttbes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = IsValidEncBatch(tpk, tags, cttbes)
+	}
+}
+
+func TestIsValidEncBatch(t *testing.T) {
+	t.Parallel()
+
+	tpk, tags, cttbes := mockBatchCttbes(t, 6)
+	testCases := []struct {
+		name   string
+		mutate func(tags []*big.Int, cttbes []*Cttbe) ([]*big.Int, []*Cttbe)
+		failed []int
+	}{
+		{
+			"all valid",
+			func(tags []*big.Int, cttbes []*Cttbe) ([]*big.Int, []*Cttbe) { return tags, cttbes },
+			nil,
+		},
+		{
+			"empty batch",
+			func(tags []*big.Int, cttbes []*Cttbe) ([]*big.Int, []*Cttbe) { return nil, nil },
+			nil,
+		},
+		{
+			"wrong tag in G1",
+			func(tags []*big.Int, cttbes []*Cttbe) ([]*big.Int, []*Cttbe) {
+				tags[2] = big.NewInt(1000)
+				return tags, cttbes
+			},
+			[]int{2},
+		},
+		{
+			"tampered cttbes in both groups",
+			func(tags []*big.Int, cttbes []*Cttbe) ([]*big.Int, []*Cttbe) {
+				c1 := *cttbes[1]
+				c1.C4, c1.C5 = c1.C5, c1.C4
+				cttbes[1] = &c1
+				c5 := *cttbes[5]
+				c5.C2 = c5.C1
+				cttbes[5] = &c5
+				return tags, cttbes
+			},
+			[]int{1, 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ts, cs := tc.mutate(append([]*big.Int{}, tags...), append([]*Cttbe{}, cttbes...))
+			failed, err := IsValidEncBatch(tpk, ts, cs)
+			require.NoError(t, err)
+			require.Equal(t, tc.failed, failed)
+		})
+	}
+
+	_, err := IsValidEncBatch(tpk, tags[1:], cttbes)
+	require.ErrorIs(t, err, ErrUnequalLenOfTagsAndCttbes)
+}
+
+// mockBatchCttbes 生成n个有效的密文，偶数下标的密文在G1中，奇数下标的在G2中
+func mockBatchCttbes(tb testing.TB, n int) (*TPK, []*big.Int, []*Cttbe) {
+	params, err := Setup(3, 2)
+	require.NoError(tb, err)
+	tags := make([]*big.Int, n)
+	cttbes := make([]*Cttbe, n)
+	for i := range cttbes {
+		var msg any = params.TPK.W1
+		if i%2 == 1 {
+			msg = params.TPK.W2
+		}
+		tags[i] = big.NewInt(int64(i + 1))
+		cttbes[i], _, _, err = Encrypt(params.TPK, tags[i], msg)
+		require.NoError(tb, err)
+	}
+
+	return params.TPK, tags, cttbes
+}
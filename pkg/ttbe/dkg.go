@@ -0,0 +1,399 @@
+package ttbe
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	"github.com/TomCN0803/taat-lib/pkg/shamir"
+	bn "github.com/cloudflare/bn256"
+)
+
+var (
+	ErrIllegalDKGParams = errors.New("illegal dkg parameters, must satisfy 1 <= t and 2t-1 <= n")
+	ErrUnexpectedMsg    = errors.New("unexpected dkg message")
+	ErrInvalidDKGMsg    = errors.New("invalid dkg message")
+)
+
+// DKG各轮次的编号
+const (
+	dkgRoundH = iota + 1
+	dkgRoundCommit
+	dkgRoundShare
+	dkgRoundInverse
+	dkgRoundKeys
+)
+
+// DKGResult 参与方在DKG结束后得到的结果，TPK与TVKs对所有参与方相同，TSK仅由本参与方持有
+type DKGResult struct {
+	TPK  *TPK
+	TSK  *TSK
+	TVKs []*TVK // TVKs[i]为id为i+1的参与方的TTBE验证密钥
+}
+
+// dkgHMsg 第1轮广播：H的贡献值H1_j = g1^h_j，H2_j = g2^h_j
+type dkgHMsg struct {
+	H1 *bn.G1
+	H2 *bn.G2
+}
+
+// dkgCommitMsg 第2轮广播：u、v、k、o多项式系数在H1下的Feldman承诺，u常数项在H2下的承诺，以及W的贡献值，
+// 其中o为常数项为0的2t-2次多项式，用于在第4轮公开μ_j时掩盖v_j*k_j
+type dkgCommitMsg struct {
+	U, V, K, O *shamir.Commitment
+	U2         *bn.G2
+	W1         *bn.G1
+	W2         *bn.G2
+}
+
+// dkgShareMsg 第3轮私密消息：发送者的u、v、k、o多项式在接收者id处的值
+type dkgShareMsg struct {
+	U, V, K, O *big.Int
+}
+
+// dkgInverseMsg 第4轮广播：μ_j = v_j*k_j + o_j，K2_j = H2^k_j，以及发送者的k常数项对U的贡献X1 = U1^k_j0，X2 = U2^k_j0
+type dkgInverseMsg struct {
+	Mu *big.Int
+	K2 *bn.G2
+	X1 *bn.G1
+	X2 *bn.G2
+}
+
+// dkgKeysMsg 第5轮广播：Z的贡献值以及发送者TVK中无法由公开承诺计算的部分
+type dkgKeysMsg struct {
+	Z1     *bn.G1
+	Z2     *bn.G2
+	U2, V2 *bn.G2 // H2^u_j，V2^v_j
+	V1     *bn.G1 // V1^v_j
+}
+
+// RunDKG 以id参与n方门限为t的TTBE分布式密钥生成，所有参与方通过tr交换消息，
+// 不存在知晓u、v的可信第三方，协议流程：
+//  1. 各方广播H的贡献值，H = ΣH_j
+//  2. 各方为u、v、k分别选取t-1次随机多项式，并选取常数项为0的2t-2次随机多项式o，
+//     广播系数的Feldman承诺与W的贡献值，U = ΣU_j，W = ΣW_j
+//  3. 各方将多项式在对方id处的值私密发送给对方，接收方根据承诺验证，u_i、v_i、k_i、o_i为各方份额之和
+//  4. 各方广播μ_i = v_i*k_i + o_i，μ = v*k由2t-1个μ_i插值得到（因此要求n >= 2t-1），V = U^(1/v) = (U^k)^(1/μ)
+//  5. 各方广播Z的贡献值与自己的TVK，Z = ΣZ_j
+//
+// 所有广播值都通过配对运算验证，任一参与方的消息无效时协议终止并返回 ErrInvalidDKGMsg
+func RunDKG(id, n, t uint64, tr Transport) (*DKGResult, error) {
+	const prefix = "failed to run ttbe dkg"
+	if t == 0 || n < 2*t-1 || id == 0 || id > n {
+		return nil, fmt.Errorf("%s: %w, got id=%d, n=%d, t=%d", prefix, ErrIllegalDKGParams, id, n, t)
+	}
+	d := &dkgParty{id: id, n: n, t: t, tr: tr}
+	for _, round := range []func() error{d.roundH, d.roundCommit, d.roundShare, d.roundInverse, d.roundKeys} {
+		if err := round(); err != nil {
+			return nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+
+	return &DKGResult{d.tpk, &TSK{id, d.ui, d.vi}, d.tvks}, nil
+}
+
+// dkgParty DKG中一个参与方的状态
+type dkgParty struct {
+	id, n, t uint64
+	tr       Transport
+
+	tpk            *TPK
+	fu, fv, fk, fo []*big.Int      // 本方的u、v、k、o多项式系数
+	k0             *big.Int        // 本方k多项式的常数项
+	commits        []*dkgCommitMsg // commits[j]为id为j+1的参与方的承诺
+	ui, vi, ki, oi *big.Int
+	tvks           []*TVK
+}
+
+func (d *dkgParty) roundH() error {
+	h, err := rand.Int(rand.Reader, bn.Order)
+	if err != nil {
+		return err
+	}
+	err = d.tr.Send(&Message{To: Broadcast, Round: dkgRoundH, Payload: &dkgHMsg{utils.NewG1(h), utils.NewG2(h)}})
+	if err != nil {
+		return err
+	}
+	msgs, err := d.receive(dkgRoundH)
+	if err != nil {
+		return err
+	}
+
+	d.tpk = &TPK{H1: utils.NewG1(big.NewInt(0)), H2: utils.NewG2(big.NewInt(0))}
+	for _, msg := range msgs {
+		m, ok := msg.Payload.(*dkgHMsg)
+		if !ok || m.H1 == nil || m.H2 == nil || !pairEquals(m.H1, utils.G2Generator(), utils.G1Generator(), m.H2) {
+			return invalidMsgErr(msg)
+		}
+		d.tpk.H1.Add(d.tpk.H1, m.H1)
+		d.tpk.H2.Add(d.tpk.H2, m.H2)
+	}
+	if utils.IsInfinity(d.tpk.H1) {
+		return fmt.Errorf("%w, H must not be the identity", ErrInvalidDKGMsg)
+	}
+
+	return nil
+}
+
+func (d *dkgParty) roundCommit() error {
+	var err error
+	secrets := make([]*big.Int, 4)
+	for i := range secrets {
+		if secrets[i], err = rand.Int(rand.Reader, bn.Order); err != nil {
+			return err
+		}
+	}
+	d.fu = shamir.GenRandPoly(d.t, secrets[0], bn.Order)
+	d.fv = shamir.GenRandPoly(d.t, secrets[1], bn.Order)
+	d.fk = shamir.GenRandPoly(d.t, secrets[2], bn.Order)
+	d.fo = shamir.GenRandPoly(2*d.t-1, big.NewInt(0), bn.Order)
+	w := secrets[3]
+	d.k0 = secrets[2]
+
+	h1, h2 := d.tpk.H1, d.tpk.H2
+	cm := &dkgCommitMsg{
+		U:  shamir.Commit(d.fu, h1, nil),
+		V:  shamir.Commit(d.fv, h1, nil),
+		K:  shamir.Commit(d.fk, h1, nil),
+		O:  shamir.Commit(d.fo, h1, nil),
+		U2: new(bn.G2).ScalarMult(h2, d.fu[0]),
+		W1: new(bn.G1).ScalarMult(h1, w),
+		W2: new(bn.G2).ScalarMult(h2, w),
+	}
+	if err = d.tr.Send(&Message{To: Broadcast, Round: dkgRoundCommit, Payload: cm}); err != nil {
+		return err
+	}
+	msgs, err := d.receive(dkgRoundCommit)
+	if err != nil {
+		return err
+	}
+
+	d.commits = make([]*dkgCommitMsg, d.n)
+	d.tpk.U1, d.tpk.U2 = utils.NewG1(big.NewInt(0)), utils.NewG2(big.NewInt(0))
+	d.tpk.W1, d.tpk.W2 = utils.NewG1(big.NewInt(0)), utils.NewG2(big.NewInt(0))
+	for _, msg := range msgs {
+		m, ok := msg.Payload.(*dkgCommitMsg)
+		if !ok || !d.validCommit(m) {
+			return invalidMsgErr(msg)
+		}
+		d.commits[msg.From-1] = m
+		d.tpk.U1.Add(d.tpk.U1, m.U.Secret())
+		d.tpk.U2.Add(d.tpk.U2, m.U2)
+		d.tpk.W1.Add(d.tpk.W1, m.W1)
+		d.tpk.W2.Add(d.tpk.W2, m.W2)
+	}
+
+	return nil
+}
+
+func (d *dkgParty) validCommit(m *dkgCommitMsg) bool {
+	if m.U2 == nil || m.W1 == nil || m.W2 == nil {
+		return false
+	}
+	h1, h2 := d.tpk.H1, d.tpk.H2
+	if m.O.Validate(h1, nil) != nil || uint64(m.O.Threshold()) != 2*d.t-1 {
+		return false
+	}
+	for _, c := range []*shamir.Commitment{m.U, m.V, m.K} {
+		if c.Validate(h1, nil) != nil || uint64(c.Threshold()) != d.t || c.G2s != nil {
+			return false
+		}
+	}
+
+	return utils.IsInfinity(m.O.Secret()) &&
+		pairEquals(m.U.Secret(), h2, h1, m.U2) && pairEquals(m.W1, h2, h1, m.W2)
+}
+
+func (d *dkgParty) roundShare() error {
+	for j := uint64(1); j <= d.n; j++ {
+		x := new(big.Int).SetUint64(j)
+		sm := &dkgShareMsg{
+			U: shamir.EvalPoly(d.fu, x, bn.Order),
+			V: shamir.EvalPoly(d.fv, x, bn.Order),
+			K: shamir.EvalPoly(d.fk, x, bn.Order),
+			O: shamir.EvalPoly(d.fo, x, bn.Order),
+		}
+		if err := d.tr.Send(&Message{To: j, Round: dkgRoundShare, Payload: sm}); err != nil {
+			return err
+		}
+	}
+	// 多项式不再需要，丢弃以免泄露
+	d.fu, d.fv, d.fk, d.fo = nil, nil, nil, nil
+
+	msgs, err := d.receive(dkgRoundShare)
+	if err != nil {
+		return err
+	}
+	x := new(big.Int).SetUint64(d.id)
+	d.ui, d.vi, d.ki, d.oi = big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	h1 := d.tpk.H1
+	for _, msg := range msgs {
+		m, ok := msg.Payload.(*dkgShareMsg)
+		if !ok || m.U == nil || m.V == nil || m.K == nil || m.O == nil {
+			return invalidMsgErr(msg)
+		}
+		c := d.commits[msg.From-1]
+		if shamir.VerifyShare(shamir.NewShare(x, m.U), c.U, h1) != nil ||
+			shamir.VerifyShare(shamir.NewShare(x, m.V), c.V, h1) != nil ||
+			shamir.VerifyShare(shamir.NewShare(x, m.K), c.K, h1) != nil ||
+			shamir.VerifyShare(shamir.NewShare(x, m.O), c.O, h1) != nil {
+			return invalidMsgErr(msg)
+		}
+		d.ui = utils.AddMod(d.ui, m.U)
+		d.vi = utils.AddMod(d.vi, m.V)
+		d.ki = utils.AddMod(d.ki, m.K)
+		d.oi = utils.AddMod(d.oi, m.O)
+	}
+
+	return nil
+}
+
+func (d *dkgParty) roundInverse() error {
+	h1, h2 := d.tpk.H1, d.tpk.H2
+	im := &dkgInverseMsg{
+		Mu: utils.AddMod(utils.MulMod(d.vi, d.ki), d.oi),
+		K2: new(bn.G2).ScalarMult(h2, d.ki),
+		X1: new(bn.G1).ScalarMult(d.tpk.U1, d.k0),
+		X2: new(bn.G2).ScalarMult(d.tpk.U2, d.k0),
+	}
+	d.k0, d.oi = nil, nil
+	if err := d.tr.Send(&Message{To: Broadcast, Round: dkgRoundInverse, Payload: im}); err != nil {
+		return err
+	}
+	msgs, err := d.receive(dkgRoundInverse)
+	if err != nil {
+		return err
+	}
+
+	xs := make([]*big.Int, len(msgs))
+	for i, msg := range msgs {
+		xs[i] = new(big.Int).SetUint64(msg.From)
+	}
+	mu := big.NewInt(0)
+	x1, x2 := utils.NewG1(big.NewInt(0)), utils.NewG2(big.NewInt(0))
+	for i, msg := range msgs {
+		m, ok := msg.Payload.(*dkgInverseMsg)
+		if !ok || m.Mu == nil || m.K2 == nil || m.X1 == nil || m.X2 == nil {
+			return invalidMsgErr(msg)
+		}
+		vj := d.pubShare(xs[i], func(c *dkgCommitMsg) *shamir.Commitment { return c.V })
+		kj := d.pubShare(xs[i], func(c *dkgCommitMsg) *shamir.Commitment { return c.K })
+		oj := d.pubShare(xs[i], func(c *dkgCommitMsg) *shamir.Commitment { return c.O })
+		k0 := d.commits[msg.From-1].K.Secret()
+		// K2_j = H2^k_j，H1^(μ_j - o_j) = H1^(v_j*k_j)，X1 = U1^k0，X2 = U2^k0
+		hvk := new(bn.G1).ScalarMult(h1, m.Mu)
+		hvk.Add(hvk, new(bn.G1).Neg(oj))
+		if !pairEquals(kj, h2, h1, m.K2) ||
+			!pairEquals(vj, m.K2, hvk, h2) ||
+			!pairEquals(m.X1, h2, k0, d.tpk.U2) ||
+			!pairEquals(h1, m.X2, k0, d.tpk.U2) {
+			return invalidMsgErr(msg)
+		}
+		mu = utils.AddMod(mu, utils.MulMod(shamir.LagCoeff(xs[i], xs, bn.Order), m.Mu))
+		x1.Add(x1, m.X1)
+		x2.Add(x2, m.X2)
+	}
+	if mu.Sign() == 0 {
+		return fmt.Errorf("%w, v*k must not be 0", ErrInvalidDKGMsg)
+	}
+	muInv := new(big.Int).ModInverse(mu, bn.Order)
+	d.tpk.V1 = new(bn.G1).ScalarMult(x1, muInv)
+	d.tpk.V2 = new(bn.G2).ScalarMult(x2, muInv)
+
+	return nil
+}
+
+func (d *dkgParty) roundKeys() error {
+	z, err := rand.Int(rand.Reader, bn.Order)
+	if err != nil {
+		return err
+	}
+	v1, v2 := d.tpk.V1, d.tpk.V2
+	km := &dkgKeysMsg{
+		Z1: new(bn.G1).ScalarMult(v1, z),
+		Z2: new(bn.G2).ScalarMult(v2, z),
+		U2: new(bn.G2).ScalarMult(d.tpk.H2, d.ui),
+		V1: new(bn.G1).ScalarMult(v1, d.vi),
+		V2: new(bn.G2).ScalarMult(v2, d.vi),
+	}
+	if err = d.tr.Send(&Message{To: Broadcast, Round: dkgRoundKeys, Payload: km}); err != nil {
+		return err
+	}
+	msgs, err := d.receive(dkgRoundKeys)
+	if err != nil {
+		return err
+	}
+
+	h1, h2 := d.tpk.H1, d.tpk.H2
+	d.tpk.Z1, d.tpk.Z2 = utils.NewG1(big.NewInt(0)), utils.NewG2(big.NewInt(0))
+	d.tvks = make([]*TVK, len(msgs))
+	for i, msg := range msgs {
+		m, ok := msg.Payload.(*dkgKeysMsg)
+		if !ok || m.Z1 == nil || m.Z2 == nil || m.U2 == nil || m.V1 == nil || m.V2 == nil {
+			return invalidMsgErr(msg)
+		}
+		x := new(big.Int).SetUint64(msg.From)
+		uj := d.pubShare(x, func(c *dkgCommitMsg) *shamir.Commitment { return c.U })
+		vj := d.pubShare(x, func(c *dkgCommitMsg) *shamir.Commitment { return c.V })
+		// Z1、Z2具有相同的离散对数，U2 = H2^u_j，V1 = V1^v_j，V2 = V2^v_j
+		if !pairEquals(m.Z1, v2, v1, m.Z2) ||
+			!pairEquals(uj, h2, h1, m.U2) ||
+			!pairEquals(m.V1, h2, vj, v2) ||
+			!pairEquals(m.V1, h2, h1, m.V2) {
+			return invalidMsgErr(msg)
+		}
+		d.tpk.Z1.Add(d.tpk.Z1, m.Z1)
+		d.tpk.Z2.Add(d.tpk.Z2, m.Z2)
+		d.tvks[i] = &TVK{msg.From, uj, m.V1, m.U2, m.V2}
+	}
+
+	return nil
+}
+
+// pubShare 由所有参与方的公开承诺计算H1^f(x)，其中f为所有参与方sel所选多项式之和
+func (d *dkgParty) pubShare(x *big.Int, sel func(c *dkgCommitMsg) *shamir.Commitment) *bn.G1 {
+	res := utils.NewG1(big.NewInt(0))
+	for _, c := range d.commits {
+		res.Add(res, sel(c).Eval(x))
+	}
+
+	return res
+}
+
+// receive 接收round轮中每个参与方恰好一条消息，并按发送者id排序
+func (d *dkgParty) receive(round int) ([]*Message, error) {
+	ids := make([]uint64, d.n)
+	for i := range ids {
+		ids[i] = uint64(i + 1)
+	}
+
+	return receiveFrom(d.tr, round, ids)
+}
+
+// receiveFrom 接收round轮中ids（升序）里每个参与方恰好一条消息，并按发送者id排序
+func receiveFrom(tr Transport, round int, ids []uint64) ([]*Message, error) {
+	msgs, err := tr.Receive(round, len(ids))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].From < msgs[j].From })
+	for i, msg := range msgs {
+		if msg.From != ids[i] {
+			return nil, fmt.Errorf("%w in round %d from party %d", ErrUnexpectedMsg, round, msg.From)
+		}
+	}
+
+	return msgs, nil
+}
+
+func invalidMsgErr(msg *Message) error {
+	return fmt.Errorf("%w in round %d from party %d", ErrInvalidDKGMsg, msg.Round, msg.From)
+}
+
+// pairEquals checks if e(a1, a2) == e(b1, b2).
+func pairEquals(a1 *bn.G1, a2 *bn.G2, b1 *bn.G1, b2 *bn.G2) bool {
+	return utils.Equals(bn.Pair(a1, a2), bn.Pair(b1, b2))
+}
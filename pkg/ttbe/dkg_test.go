@@ -0,0 +1,242 @@
+package ttbe
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDKG(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		n, t uint64
+		inG1 bool
+	}{
+		{
+			"n=1, t=1",
+			1,
+			1,
+			true,
+		},
+		{
+			"n=3, t=2, message in G1",
+			3,
+			2,
+			true,
+		},
+		{
+			"n=5, t=3, message in G2",
+			5,
+			3,
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ln := NewLocalNetwork(tc.n)
+			results, errs := runDKGParties(ln, tc.n, tc.t, nil)
+			for _, err := range errs {
+				require.NoError(t, err)
+			}
+
+			tpk := results[0].TPK
+			for i, res := range results {
+				require.Equal(t, tpk.Marshal(), res.TPK.Marshal())
+				require.Equal(t, uint64(i+1), res.TSK.ID())
+				require.Len(t, res.TVKs, int(tc.n))
+				for j, tvk := range res.TVKs {
+					require.Equal(t, results[0].TVKs[j].Marshal(), tvk.Marshal())
+				}
+			}
+
+			var msg any
+			if tc.inG1 {
+				_, msg, _ = bn.RandomG1(rand.Reader)
+			} else {
+				_, msg, _ = bn.RandomG2(rand.Reader)
+			}
+			tag := big.NewInt(7)
+			cttbe, _, _, err := Encrypt(tpk, tag, msg)
+			require.NoError(t, err)
+			require.True(t, IsValidEnc(tpk, tag, cttbe))
+
+			// 使用最后t个参与方的密钥解密
+			tvks := make([]*TVK, 0, tc.t)
+			clues := make([]*AudClue, 0, tc.t)
+			for _, res := range results[tc.n-tc.t:] {
+				clue, err := ShareAudClue(tpk, tag, cttbe, res.TSK)
+				require.NoError(t, err)
+				tvk := res.TVKs[res.TSK.ID()-1]
+				require.True(t, IsValidAudClue(tpk, tag, cttbe, tvk, clue))
+				tvks = append(tvks, tvk)
+				clues = append(clues, clue)
+			}
+			got, err := Combine(tpk, tag, cttbe, tvks, clues)
+			require.NoError(t, err)
+			if tc.inG1 {
+				require.True(t, utils.Equals(msg.(*bn.G1), got.(*bn.G1)))
+			} else {
+				require.True(t, utils.Equals(msg.(*bn.G2), got.(*bn.G2)))
+			}
+		})
+	}
+}
+
+func TestRunDKGIllegalParams(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		id, n, t uint64
+	}{
+		{
+			"zero threshold",
+			1,
+			3,
+			0,
+		},
+		{
+			"too few parties",
+			1,
+			4,
+			3,
+		},
+		{
+			"zero id",
+			0,
+			3,
+			2,
+		},
+		{
+			"id out of range",
+			4,
+			3,
+			2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := RunDKG(tc.id, tc.n, tc.t, NewLocalNetwork(tc.n).Endpoint(tc.id))
+			require.ErrorIs(t, err, ErrIllegalDKGParams)
+		})
+	}
+}
+
+func TestRunDKGCheatingParty(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		round  int
+		tamper func(payload any)
+	}{
+		{
+			"bad share",
+			dkgRoundShare,
+			func(payload any) {
+				m := payload.(*dkgShareMsg)
+				m.U = utils.AddMod(m.U, big.NewInt(1))
+			},
+		},
+		{
+			"bad zero sharing",
+			dkgRoundCommit,
+			func(payload any) {
+				m := payload.(*dkgCommitMsg)
+				m.O.G1s[0] = utils.G1Generator()
+			},
+		},
+		{
+			"bad mu",
+			dkgRoundInverse,
+			func(payload any) {
+				m := payload.(*dkgInverseMsg)
+				m.Mu = utils.AddMod(m.Mu, big.NewInt(1))
+			},
+		},
+		{
+			"bad tvk",
+			dkgRoundKeys,
+			func(payload any) {
+				m := payload.(*dkgKeysMsg)
+				m.V1 = new(bn.G1).Add(m.V1, utils.G1Generator())
+			},
+		},
+	}
+
+	const cheater = 2
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ln := NewLocalNetwork(3)
+			wrap := func(id uint64, tr Transport) Transport {
+				if id != cheater {
+					return tr
+				}
+				return &tamperTransport{tr, tc.round, tc.tamper}
+			}
+			_, errs := runDKGParties(ln, 3, 2, wrap)
+			for id, err := range errs {
+				if uint64(id+1) == cheater {
+					continue
+				}
+				require.ErrorIs(t, err, ErrInvalidDKGMsg)
+				require.ErrorContains(t, err, "from party 2")
+			}
+		})
+	}
+}
+
+// runDKGParties 在ln上并发运行n个参与方的DKG，任一参与方出错时终止网络以免其他参与方阻塞，
+// wrap不为nil时用于替换参与方使用的 Transport
+func runDKGParties(ln *LocalNetwork, n, t uint64, wrap func(id uint64, tr Transport) Transport) ([]*DKGResult, []error) {
+	results := make([]*DKGResult, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := uint64(0); i < n; i++ {
+		wg.Add(1)
+		go func(id uint64) {
+			defer wg.Done()
+			tr := ln.Endpoint(id)
+			if wrap != nil {
+				tr = wrap(id, tr)
+			}
+			results[id-1], errs[id-1] = RunDKG(id, n, t, tr)
+			if errs[id-1] != nil {
+				ln.Abort(errs[id-1])
+			}
+		}(i + 1)
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+// tamperTransport 在发送round轮消息前使用tamper篡改消息内容，模拟作恶的参与方
+type tamperTransport struct {
+	Transport
+	round  int
+	tamper func(payload any)
+}
+
+func (tt *tamperTransport) Send(msg *Message) error {
+	if msg.Round == tt.round {
+		tt.tamper(msg.Payload)
+	}
+
+	return tt.Transport.Send(msg)
+}
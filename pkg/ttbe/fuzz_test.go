@@ -0,0 +1,73 @@
+package ttbe
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// unmarshaler 可以由字节切片反序列化并重新序列化的类型
+type unmarshaler interface {
+	Marshal() []byte
+	Unmarshal([]byte) error
+}
+
+// fuzzUnmarshal 检查任意输入都不会使Unmarshal panic，且成功反序列化的输入重新序列化后保持不变
+func fuzzUnmarshal(f *testing.F, newV func() unmarshaler, seeds ...[]byte) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Add([]byte{})
+	f.Add([]byte{1})
+	f.Fuzz(func(t *testing.T, buff []byte) {
+		v := newV()
+		if err := v.Unmarshal(buff); err != nil {
+			return
+		}
+		if !bytes.Equal(v.Marshal(), buff) {
+			t.Fatalf("re-marshaled result differs from input %x", buff)
+		}
+	})
+}
+
+func fuzzParams(f *testing.F) (*Parameters, *Cttbe, *AudClue) {
+	params, err := Setup(3, 2)
+	if err != nil {
+		f.Fatal(err)
+	}
+	cttbe, _, _, err := Encrypt(params.TPK, big.NewInt(1), params.TPK.H2)
+	if err != nil {
+		f.Fatal(err)
+	}
+	clue, err := ShareAudClue(params.TPK, big.NewInt(1), cttbe, params.TSKs[0])
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	return params, cttbe, clue
+}
+
+func FuzzCttbeUnmarshal(f *testing.F) {
+	_, cttbe, _ := fuzzParams(f)
+	fuzzUnmarshal(f, func() unmarshaler { return new(Cttbe) }, cttbe.Marshal(), mockRandomCttbe(true).Marshal())
+}
+
+func FuzzTPKUnmarshal(f *testing.F) {
+	params, _, _ := fuzzParams(f)
+	fuzzUnmarshal(f, func() unmarshaler { return new(TPK) }, params.TPK.Marshal())
+}
+
+func FuzzTSKUnmarshal(f *testing.F) {
+	params, _, _ := fuzzParams(f)
+	fuzzUnmarshal(f, func() unmarshaler { return new(TSK) }, params.TSKs[0].Marshal())
+}
+
+func FuzzTVKUnmarshal(f *testing.F) {
+	params, _, _ := fuzzParams(f)
+	fuzzUnmarshal(f, func() unmarshaler { return new(TVK) }, params.TVKs[0].Marshal())
+}
+
+func FuzzAudClueUnmarshal(f *testing.F) {
+	_, _, clue := fuzzParams(f)
+	fuzzUnmarshal(f, func() unmarshaler { return new(AudClue) }, clue.Marshal())
+}
@@ -0,0 +1,132 @@
+package ttbe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+)
+
+var ErrDecryptPayload = errors.New("failed to authenticate and decrypt payload")
+
+const (
+	hybridKDFDomain = "taat-lib/ttbe/hybrid/v1" // 派生对称密钥时使用的域分隔串
+	hybridNonceSize = 12                        // AES-GCM标准nonce长度
+	hybridTagSize   = 16                        // AES-GCM认证标签长度，即aead.Overhead()
+)
+
+// HybridCttbe TTBE混合密文，Cttbe加密一个随机的G1元素，由该元素派生的AES-256-GCM密钥加密任意长度的负载
+type HybridCttbe struct {
+	Cttbe      *Cttbe
+	Nonce      []byte
+	Ciphertext []byte // AEAD密文，包含认证标签
+}
+
+// EncryptBytes 在tpk和tag下加密任意字节负载payload，tag与Cttbe作为AEAD的附加数据，
+// 审计者对返回值中的Cttbe调用 ShareAudClue 生成审计线索，再由 CombineBytes 恢复负载
+func EncryptBytes(tpk *TPK, tag *big.Int, payload []byte) (*HybridCttbe, error) {
+	const prefix = "failed to encrypt bytes"
+	_, m, err := bn.RandomG1(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	cttbe, _, _, err := Encrypt(tpk, tag, m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	aead, err := hybridAEAD(m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	ct := aead.Seal(nil, nonce, payload, hybridAD(tag, cttbe))
+
+	return &HybridCttbe{cttbe, nonce, ct}, nil
+}
+
+// CombineBytes 根据审计线索恢复hc中的负载，tvks与clues的要求同 Combine，
+// 负载被篡改或者不是在tag下加密的时候返回 ErrDecryptPayload
+func CombineBytes(tpk *TPK, tag *big.Int, hc *HybridCttbe, tvks []*TVK, clues []*AudClue) ([]byte, error) {
+	const prefix = "failed to combine bytes"
+	m, err := Combine(tpk, tag, hc.Cttbe, tvks, clues)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	aead, err := hybridAEAD(m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	if len(hc.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrDecryptPayload)
+	}
+	payload, err := aead.Open(nil, hc.Nonce, hc.Ciphertext, hybridAD(tag, hc.Cttbe))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", prefix, ErrDecryptPayload)
+	}
+
+	return payload, nil
+}
+
+// Marshal marshals HybridCttbe as Cttbe || nonce || ciphertext.
+func (hc *HybridCttbe) Marshal() []byte {
+	res := hc.Cttbe.Marshal()
+	res = append(res, hc.Nonce...)
+
+	return append(res, hc.Ciphertext...)
+}
+
+// Unmarshal reads from byte slice buff produced by HybridCttbe.Marshal and sets hc to the result,
+// the ciphertext must be at least as long as the AES-GCM tag.
+func (hc *HybridCttbe) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal hybrid cttbe"
+	d := utils.NewDecoder(buff)
+	cttbe, err := readCttbe(d)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	nonce, err := d.ReadBytes(hybridNonceSize)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if d.Len() < hybridTagSize {
+		return fmt.Errorf("%s: %w, ciphertext shorter than the %d-byte tag", prefix, utils.ErrShortBuffer, hybridTagSize)
+	}
+	ct, _ := d.ReadBytes(d.Len())
+	*hc = HybridCttbe{cttbe, append([]byte{}, nonce...), append([]byte{}, ct...)}
+
+	return nil
+}
+
+// hybridAEAD 由群元素m派生AES-256-GCM实例，key = SHA256(domain || m)
+func hybridAEAD(m any) (cipher.AEAD, error) {
+	buff, err := utils.AppendElem([]byte(hybridKDFDomain), m)
+	if err != nil {
+		return nil, err
+	}
+	key := sha256.Sum256(buff)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCMWithNonceSize(block, hybridNonceSize)
+}
+
+// hybridAD 返回AEAD附加数据：len(tag) || tag || cttbe
+func hybridAD(tag *big.Int, cttbe *Cttbe) []byte {
+	tb := tag.Bytes()
+	res := binary.BigEndian.AppendUint32(nil, uint32(len(tb)))
+	res = append(res, tb...)
+
+	return append(res, cttbe.Marshal()...)
+}
@@ -0,0 +1,102 @@
+package ttbe
+
+import (
+	"math/big"
+	"testing"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptBytes(t *testing.T) {
+	t.Parallel()
+
+	params, err := Setup(5, 3)
+	require.NoError(t, err)
+	tag := big.NewInt(2023)
+	payload := []byte("amount=100;memo=rent;counterpart=bob")
+	hc, err := EncryptBytes(params.TPK, tag, payload)
+	require.NoError(t, err)
+
+	clues := make([]*AudClue, 3)
+	for i := range clues {
+		clues[i], err = ShareAudClue(params.TPK, tag, hc.Cttbe, params.TSKs[i+1])
+		require.NoError(t, err)
+	}
+	tvks := params.TVKs[1:4]
+
+	testCases := []struct {
+		name   string
+		tag    *big.Int
+		mutate func(hc *HybridCttbe)
+		err    error
+	}{
+		{
+			"happy path",
+			tag,
+			func(hc *HybridCttbe) {},
+			nil,
+		},
+		{
+			"tampered ciphertext",
+			tag,
+			func(hc *HybridCttbe) { hc.Ciphertext[0] ^= 1 },
+			ErrDecryptPayload,
+		},
+		{
+			"tampered nonce",
+			tag,
+			func(hc *HybridCttbe) { hc.Nonce[0] ^= 1 },
+			ErrDecryptPayload,
+		},
+		{
+			"truncated ciphertext",
+			tag,
+			func(hc *HybridCttbe) { hc.Ciphertext = hc.Ciphertext[:len(hc.Ciphertext)-1] },
+			ErrDecryptPayload,
+		},
+		{
+			"wrong tag",
+			big.NewInt(2024),
+			func(hc *HybridCttbe) {},
+			ErrInvalidCttbe,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			// 经过序列化得到独立的副本
+			c := new(HybridCttbe)
+			require.NoError(t, c.Unmarshal(hc.Marshal()))
+			tc.mutate(c)
+			got, err := CombineBytes(params.TPK, tc.tag, c, tvks, clues)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, payload, got)
+		})
+	}
+}
+
+func TestHybridCttbeUnmarshalMalformed(t *testing.T) {
+	t.Parallel()
+
+	params, err := Setup(3, 2)
+	require.NoError(t, err)
+	hc, err := EncryptBytes(params.TPK, big.NewInt(1), nil)
+	require.NoError(t, err)
+	cttbeSize := len(hc.Cttbe.Marshal())
+	buff := hc.Marshal()
+	require.Len(t, buff, cttbeSize+hybridNonceSize+hybridTagSize)
+
+	require.ErrorIs(t, new(HybridCttbe).Unmarshal(buff[:cttbeSize-1]), utils.ErrShortBuffer)
+	require.ErrorIs(t, new(HybridCttbe).Unmarshal(buff[:cttbeSize+hybridNonceSize-1]), utils.ErrShortBuffer)
+	require.ErrorIs(t, new(HybridCttbe).Unmarshal(buff[:cttbeSize+hybridNonceSize]), utils.ErrShortBuffer)
+	require.ErrorIs(t, new(HybridCttbe).Unmarshal(buff[:len(buff)-1]), utils.ErrShortBuffer)
+	require.NoError(t, new(HybridCttbe).Unmarshal(buff))
+	require.ErrorIs(t, new(HybridCttbe).Unmarshal(append([]byte{2}, buff[1:]...)), ErrIllegalInG1Byte)
+}
@@ -0,0 +1,151 @@
+package ttbe
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrUnknownParty   = errors.New("unknown party id")
+	ErrNetworkAborted = errors.New("network aborted")
+)
+
+// Broadcast 作为 Message.To 时表示消息发送给所有参与方（包括发送者自己）
+const Broadcast uint64 = 0
+
+// Message 协议参与方之间传递的消息。每个接收者收到各自的 Message 副本，
+// 但Payload不会被复制，广播时所有接收者共享同一个Payload，因此接收者须将其视为只读
+type Message struct {
+	From    uint64 // 发送者id，由 Transport 设置
+	To      uint64 // 接收者id，Broadcast 表示广播
+	Round   int    // 消息所属的协议轮次
+	Payload any
+}
+
+// Transport 参与方收发协议消息的接口，实现需要保证：
+//  1. Send 发送的消息的From为本参与方的id
+//  2. 广播消息会同样发送给发送者自己
+//  3. Receive 阻塞直至收到round轮的count条消息，其他轮次的消息会被保留以供之后读取
+type Transport interface {
+	Send(msg *Message) error
+	Receive(round, count int) ([]*Message, error)
+}
+
+// LocalNetwork 进程内的 Transport 实现，用于在同一进程中运行并测试多方协议
+type LocalNetwork struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	inboxes map[uint64][]*Message
+	err     error
+}
+
+// NewLocalNetwork 创建id为1...n的参与方组成的 LocalNetwork
+func NewLocalNetwork(n uint64) *LocalNetwork {
+	ids := make([]uint64, n)
+	for i := range ids {
+		ids[i] = uint64(i + 1)
+	}
+	return NewLocalNetworkWithIDs(ids)
+}
+
+// NewLocalNetworkWithIDs 创建由ids中的参与方组成的 LocalNetwork
+func NewLocalNetworkWithIDs(ids []uint64) *LocalNetwork {
+	ln := &LocalNetwork{inboxes: make(map[uint64][]*Message, len(ids))}
+	ln.cond = sync.NewCond(&ln.mu)
+	for _, id := range ids {
+		ln.inboxes[id] = nil
+	}
+
+	return ln
+}
+
+// Endpoint 返回参与方id使用的 Transport
+func (ln *LocalNetwork) Endpoint(id uint64) Transport {
+	return &localEndpoint{ln, id}
+}
+
+// Abort 终止网络，所有阻塞及之后的 Transport 调用都会返回包含err的错误
+func (ln *LocalNetwork) Abort(err error) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	if ln.err == nil {
+		ln.err = fmt.Errorf("%w: %w", ErrNetworkAborted, err)
+	}
+	ln.cond.Broadcast()
+}
+
+type localEndpoint struct {
+	ln *LocalNetwork
+	id uint64
+}
+
+func (e *localEndpoint) Send(msg *Message) error {
+	ln := e.ln
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	if ln.err != nil {
+		return ln.err
+	}
+	if _, ok := ln.inboxes[e.id]; !ok {
+		return fmt.Errorf("%w %d", ErrUnknownParty, e.id)
+	}
+
+	if msg.To == Broadcast {
+		for id := range ln.inboxes {
+			ln.inboxes[id] = append(ln.inboxes[id], e.stamp(msg))
+		}
+	} else {
+		if _, ok := ln.inboxes[msg.To]; !ok {
+			return fmt.Errorf("%w %d", ErrUnknownParty, msg.To)
+		}
+		ln.inboxes[msg.To] = append(ln.inboxes[msg.To], e.stamp(msg))
+	}
+	ln.cond.Broadcast()
+
+	return nil
+}
+
+// stamp 返回msg的副本，其From为本参与方的id
+func (e *localEndpoint) stamp(msg *Message) *Message {
+	m := *msg
+	m.From = e.id
+
+	return &m
+}
+
+func (e *localEndpoint) Receive(round, count int) ([]*Message, error) {
+	ln := e.ln
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	for {
+		if ln.err != nil {
+			return nil, ln.err
+		}
+		inbox, ok := ln.inboxes[e.id]
+		if !ok {
+			return nil, fmt.Errorf("%w %d", ErrUnknownParty, e.id)
+		}
+
+		n := 0
+		for _, m := range inbox {
+			if m.Round == round {
+				n++
+			}
+		}
+		if n >= count {
+			res := make([]*Message, 0, count)
+			rest := inbox[:0]
+			for _, m := range inbox {
+				if m.Round == round && len(res) < count {
+					res = append(res, m)
+				} else {
+					rest = append(rest, m)
+				}
+			}
+			ln.inboxes[e.id] = rest
+			return res, nil
+		}
+		ln.cond.Wait()
+	}
+}
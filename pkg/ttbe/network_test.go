@@ -0,0 +1,30 @@
+package ttbe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalNetworkBroadcast(t *testing.T) {
+	t.Parallel()
+
+	ln := NewLocalNetwork(3)
+	payload := []byte("payload")
+	require.NoError(t, ln.Endpoint(1).Send(&Message{To: Broadcast, Round: 1, Payload: payload}))
+
+	msgs := make([]*Message, 3)
+	for i := range msgs {
+		res, err := ln.Endpoint(uint64(i+1)).Receive(1, 1)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		msgs[i] = res[0]
+		require.Equal(t, uint64(1), msgs[i].From)
+		require.Equal(t, payload, msgs[i].Payload)
+	}
+	// 每个接收者持有各自的 Message，修改其中一个不影响其他接收者
+	require.NotSame(t, msgs[0], msgs[1])
+	msgs[0].Round = 2
+	require.Equal(t, 1, msgs[1].Round)
+	require.Equal(t, 1, msgs[2].Round)
+}
@@ -0,0 +1,175 @@
+package ttbe
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	"github.com/TomCN0803/taat-lib/pkg/shamir"
+	bn "github.com/cloudflare/bn256"
+)
+
+var ErrIllegalRefreshParams = errors.New("illegal refresh parameters")
+
+// 份额刷新各轮次的编号
+const (
+	refreshRoundCommit = iota + 1
+	refreshRoundShare
+)
+
+// refreshCommitMsg 第1轮广播：δu、δv两个常数项为0的多项式的Feldman承诺，
+// δu以(H1, H2)为基，δv以(V1, V2)为基，使得各方能够公开地更新所有TVK
+type refreshCommitMsg struct {
+	DU, DV *shamir.Commitment
+}
+
+// refreshShareMsg 第2轮私密消息：发送者的δu、δv在接收者id处的值
+type refreshShareMsg struct {
+	DU, DV *big.Int
+}
+
+// RunRefresh 以tsk的持有者身份参与门限为t的TTBE份额刷新，tvks为当前所有审计者的TVK，
+// 所有审计者都需要通过tr参与，协议流程：
+//  1. 各方选取常数项为0的t-1次随机多项式δu_j、δv_j，广播其承诺
+//  2. 各方将多项式在对方id处的值私密发送给对方，接收方根据承诺验证，
+//     u_i' = u_i + Σδu_j(i)，v_i' = v_i + Σδv_j(i)
+//
+// u、v以及TPK保持不变，已有的Cttbe仍可被解密，但刷新前后的审计线索无法合并使用。
+// 返回本方新的TSK以及与tvks顺序相同的新TVKs，任一参与方的消息无效时返回 ErrInvalidDKGMsg
+func RunRefresh(tpk *TPK, tsk *TSK, tvks []*TVK, t uint64, tr Transport) (*TSK, []*TVK, error) {
+	const prefix = "failed to refresh ttbe shares"
+	ids, err := refreshIDs(tsk, tvks, t)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	du := shamir.GenRandPoly(t, big.NewInt(0), bn.Order)
+	dv := shamir.GenRandPoly(t, big.NewInt(0), bn.Order)
+	cm := &refreshCommitMsg{
+		DU: shamir.Commit(du, tpk.H1, tpk.H2),
+		DV: shamir.Commit(dv, tpk.V1, tpk.V2),
+	}
+	if err = tr.Send(&Message{To: Broadcast, Round: refreshRoundCommit, Payload: cm}); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	for _, id := range ids {
+		x := new(big.Int).SetUint64(id)
+		sm := &refreshShareMsg{shamir.EvalPoly(du, x, bn.Order), shamir.EvalPoly(dv, x, bn.Order)}
+		if err = tr.Send(&Message{To: id, Round: refreshRoundShare, Payload: sm}); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+
+	commits, err := receiveRefreshCommits(tpk, tr, ids, t)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	msgs, err := receiveFrom(tr, refreshRoundShare, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	x := new(big.Int).SetUint64(tsk.id)
+	newTSK := &TSK{tsk.id, tsk.u, tsk.v}
+	for i, msg := range msgs {
+		m, ok := msg.Payload.(*refreshShareMsg)
+		if !ok || m.DU == nil || m.DV == nil ||
+			shamir.VerifyShare(shamir.NewShare(x, m.DU), commits[i].DU, tpk.H1) != nil ||
+			shamir.VerifyShare(shamir.NewShare(x, m.DV), commits[i].DV, tpk.V1) != nil {
+			return nil, nil, fmt.Errorf("%s: %w", prefix, invalidMsgErr(msg))
+		}
+		newTSK.u = utils.AddMod(newTSK.u, m.DU)
+		newTSK.v = utils.AddMod(newTSK.v, m.DV)
+	}
+
+	newTVKs := make([]*TVK, len(tvks))
+	for i, tvk := range tvks {
+		x := new(big.Int).SetUint64(tvk.id)
+		res := &TVK{
+			tvk.id,
+			new(bn.G1).Set(tvk.u1), new(bn.G1).Set(tvk.v1),
+			new(bn.G2).Set(tvk.u2), new(bn.G2).Set(tvk.v2),
+		}
+		for _, c := range commits {
+			res.u1.Add(res.u1, c.DU.Eval(x))
+			res.v1.Add(res.v1, c.DV.Eval(x))
+			res.u2.Add(res.u2, c.DU.EvalG2(x))
+			res.v2.Add(res.v2, c.DV.EvalG2(x))
+		}
+		newTVKs[i] = res
+	}
+
+	return newTSK, newTVKs, nil
+}
+
+// receiveRefreshCommits 接收并验证所有参与方的承诺，承诺需与基一致且常数项为0
+func receiveRefreshCommits(tpk *TPK, tr Transport, ids []uint64, t uint64) ([]*refreshCommitMsg, error) {
+	msgs, err := receiveFrom(tr, refreshRoundCommit, ids)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]*refreshCommitMsg, len(msgs))
+	for i, msg := range msgs {
+		m, ok := msg.Payload.(*refreshCommitMsg)
+		if !ok || !validZeroCommit(m.DU, tpk.H1, tpk.H2, t) || !validZeroCommit(m.DV, tpk.V1, tpk.V2, t) {
+			return nil, invalidMsgErr(msg)
+		}
+		commits[i] = m
+	}
+
+	return commits, nil
+}
+
+// validZeroCommit 检查c是以(g1, g2)为基、门限为t且常数项为0的多项式承诺
+func validZeroCommit(c *shamir.Commitment, g1 *bn.G1, g2 *bn.G2, t uint64) bool {
+	return c.Validate(g1, g2) == nil && c.G2s != nil &&
+		uint64(c.Threshold()) == t && utils.IsInfinity(c.Secret())
+}
+
+// refreshIDs 检查刷新参数，返回升序排列的所有审计者id
+func refreshIDs(tsk *TSK, tvks []*TVK, t uint64) ([]uint64, error) {
+	if tsk == nil || t == 0 || uint64(len(tvks)) < t {
+		return nil, fmt.Errorf("%w, got %d tvks with t=%d", ErrIllegalRefreshParams, len(tvks), t)
+	}
+	ids, err := tvkIDs(tvks)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %v", ErrIllegalRefreshParams, err)
+	}
+	if !containsID(ids, tsk.id) {
+		return nil, fmt.Errorf("%w, tsk id %d not in tvks", ErrIllegalRefreshParams, tsk.id)
+	}
+
+	return ids, nil
+}
+
+// tvkIDs 返回tvks的id组成的升序列表，id为0或重复时返回错误
+func tvkIDs(tvks []*TVK) ([]uint64, error) {
+	ids := make([]uint64, len(tvks))
+	for i, tvk := range tvks {
+		if tvk == nil {
+			return nil, errors.New("nil tvk")
+		}
+		ids[i] = tvk.id
+	}
+
+	return sortIDs(ids)
+}
+
+// sortIDs 返回ids的升序副本，id为0或重复时返回错误
+func sortIDs(ids []uint64) ([]uint64, error) {
+	res := append([]uint64{}, ids...)
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	for i, id := range res {
+		if id == 0 || i > 0 && res[i-1] == id {
+			return nil, fmt.Errorf("illegal or duplicate id %d", id)
+		}
+	}
+
+	return res, nil
+}
+
+func containsID(ids []uint64, id uint64) bool {
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	return i < len(ids) && ids[i] == id
+}
@@ -0,0 +1,221 @@
+package ttbe
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	bn "github.com/cloudflare/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRefresh(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		inG1 bool
+	}{
+		{
+			"message in G1",
+			true,
+		},
+		{
+			"message in G2",
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			params, err := Setup(5, 3)
+			require.NoError(t, err)
+			var msg any
+			if tc.inG1 {
+				_, msg, _ = bn.RandomG1(rand.Reader)
+			} else {
+				_, msg, _ = bn.RandomG2(rand.Reader)
+			}
+			tag := big.NewInt(3)
+			cttbe, _, _, err := Encrypt(params.TPK, tag, msg)
+			require.NoError(t, err)
+
+			tsks, tvkss, errs := runRefreshParties(params, 3, nil)
+			for _, err := range errs {
+				require.NoError(t, err)
+			}
+			for i := range tsks {
+				require.Equal(t, params.TSKs[i].ID(), tsks[i].ID())
+				require.NotZero(t, tsks[i].u.Cmp(params.TSKs[i].u))
+				for j, tvk := range tvkss[i] {
+					require.Equal(t, tvkss[0][j].Marshal(), tvk.Marshal())
+				}
+			}
+			tvks := tvkss[0]
+
+			// 刷新后的份额仍能解密刷新前的密文
+			newTVKs := make([]*TVK, 0, 3)
+			newClues := make([]*AudClue, 0, 3)
+			for _, i := range []int{0, 2, 4} {
+				clue, err := ShareAudClue(params.TPK, tag, cttbe, tsks[i])
+				require.NoError(t, err)
+				require.True(t, IsValidAudClue(params.TPK, tag, cttbe, tvks[i], clue))
+				newTVKs = append(newTVKs, tvks[i])
+				newClues = append(newClues, clue)
+			}
+			got, err := Combine(params.TPK, tag, cttbe, newTVKs, newClues)
+			require.NoError(t, err)
+			requireElemEqual(t, tc.inG1, msg, got, true)
+
+			// 刷新前的线索与刷新后的线索无法合并
+			oldClue, err := ShareAudClue(params.TPK, tag, cttbe, params.TSKs[1])
+			require.NoError(t, err)
+			require.False(t, IsValidAudClue(params.TPK, tag, cttbe, tvks[1], oldClue))
+			mixedTVKs := []*TVK{newTVKs[0], newTVKs[1], params.TVKs[1]}
+			mixedClues := []*AudClue{newClues[0], newClues[1], oldClue}
+			got, err = Combine(params.TPK, tag, cttbe, mixedTVKs, mixedClues)
+			require.NoError(t, err)
+			requireElemEqual(t, tc.inG1, msg, got, false)
+		})
+	}
+}
+
+func TestRunRefreshIllegalParams(t *testing.T) {
+	t.Parallel()
+
+	params, err := Setup(3, 2)
+	require.NoError(t, err)
+	testCases := []struct {
+		name string
+		tsk  *TSK
+		tvks []*TVK
+		t    uint64
+	}{
+		{
+			"zero threshold",
+			params.TSKs[0],
+			params.TVKs,
+			0,
+		},
+		{
+			"too few tvks",
+			params.TSKs[0],
+			params.TVKs[:1],
+			2,
+		},
+		{
+			"tsk not in tvks",
+			params.TSKs[2],
+			params.TVKs[:2],
+			2,
+		},
+		{
+			"duplicate id",
+			params.TSKs[0],
+			[]*TVK{params.TVKs[0], params.TVKs[0], params.TVKs[1]},
+			2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := RunRefresh(params.TPK, tc.tsk, tc.tvks, tc.t, NewLocalNetwork(3).Endpoint(1))
+			require.ErrorIs(t, err, ErrIllegalRefreshParams)
+		})
+	}
+}
+
+func TestRunRefreshCheatingParty(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		round  int
+		tamper func(payload any)
+	}{
+		{
+			"bad share",
+			refreshRoundShare,
+			func(payload any) {
+				m := payload.(*refreshShareMsg)
+				m.DV = utils.AddMod(m.DV, big.NewInt(1))
+			},
+		},
+		{
+			"non-zero secret",
+			refreshRoundCommit,
+			func(payload any) {
+				m := payload.(*refreshCommitMsg)
+				m.DU.G1s[0] = utils.G1Generator()
+				m.DU.G2s[0] = utils.G2Generator()
+			},
+		},
+	}
+
+	params, err := Setup(3, 2)
+	require.NoError(t, err)
+	const cheater = 2
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			wrap := func(id uint64, tr Transport) Transport {
+				if id != cheater {
+					return tr
+				}
+				return &tamperTransport{tr, tc.round, tc.tamper}
+			}
+			_, _, errs := runRefreshParties(params, 2, wrap)
+			for id, err := range errs {
+				if uint64(id+1) == cheater {
+					continue
+				}
+				require.ErrorIs(t, err, ErrInvalidDKGMsg)
+				require.ErrorContains(t, err, "from party 2")
+			}
+		})
+	}
+}
+
+// runRefreshParties 在新的 LocalNetwork 上并发运行params中所有审计者的份额刷新
+func runRefreshParties(params *Parameters, t uint64, wrap func(id uint64, tr Transport) Transport) ([]*TSK, [][]*TVK, []error) {
+	n := len(params.TSKs)
+	ln := NewLocalNetwork(uint64(n))
+	tsks := make([]*TSK, n)
+	tvkss := make([][]*TVK, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tsk := params.TSKs[i]
+			tr := ln.Endpoint(tsk.ID())
+			if wrap != nil {
+				tr = wrap(tsk.ID(), tr)
+			}
+			tsks[i], tvkss[i], errs[i] = RunRefresh(params.TPK, tsk, params.TVKs, t, tr)
+			if errs[i] != nil {
+				ln.Abort(errs[i])
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return tsks, tvkss, errs
+}
+
+func requireElemEqual(t *testing.T, inG1 bool, expected, actual any, equal bool) {
+	t.Helper()
+	if inG1 {
+		require.Equal(t, equal, utils.Equals(expected.(*bn.G1), actual.(*bn.G1)))
+	} else {
+		require.Equal(t, equal, utils.Equals(expected.(*bn.G2), actual.(*bn.G2)))
+	}
+}
@@ -0,0 +1,184 @@
+package ttbe
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	"github.com/TomCN0803/taat-lib/pkg/shamir"
+	bn "github.com/cloudflare/bn256"
+)
+
+var ErrIllegalReshareParams = errors.New("illegal reshare parameters")
+
+// 重新分享各轮次的编号
+const (
+	reshareRoundCommit = iota + 1
+	reshareRoundShare
+)
+
+// ReshareConfig 重新分享的参数，所有参与方的配置需保持一致（ID除外）。
+// 参与方在网络中的id即其审计者id，同时属于新旧委员会的审计者使用同一id
+type ReshareConfig struct {
+	ID      uint64   // 本参与方的id
+	OldTVKs []*TVK   // 参与重新分享的旧审计者的TVK，数量不少于OldT
+	OldT    uint64   // 旧委员会的门限
+	NewIDs  []uint64 // 新委员会审计者的id
+	NewT    uint64   // 新委员会的门限
+}
+
+// reshareCommitMsg 第1轮广播：旧审计者i以u_i、v_i为常数项的t'-1次多项式的承诺，
+// u部分以(H1, H2)为基，v部分以(V1, V2)为基
+type reshareCommitMsg struct {
+	U, V *shamir.Commitment
+}
+
+// reshareShareMsg 第2轮私密消息：旧审计者的两个多项式在新审计者id处的值
+type reshareShareMsg struct {
+	U, V *big.Int
+}
+
+// RunReshare 参与将TTBE私钥u、v从旧委员会重新分享给门限为NewT的新委员会，TPK保持不变。
+// tsk为本方作为旧审计者的TSK，本方不在cfg.OldTVKs中时为nil，协议流程：
+//  1. 每个旧审计者i选取常数项分别为u_i、v_i的t'-1次随机多项式，广播其承诺，
+//     各方通过比较承诺的常数项与旧TVK验证旧审计者分享的是自己的份额
+//  2. 旧审计者将多项式在新审计者id处的值私密发送给对方，新审计者j根据承诺验证，
+//     以旧审计者id处的拉格朗日系数λ_i合并：u_j' = Σλ_i*f_i(j)，v_j' = Σλ_i*g_i(j)
+//
+// 返回本方新的TSK（本方不在新委员会中时为nil）以及与cfg.NewIDs顺序相同的新TVKs，
+// 任一旧审计者的消息无效时返回 ErrInvalidDKGMsg
+func RunReshare(tpk *TPK, tsk *TSK, cfg *ReshareConfig, tr Transport) (*TSK, []*TVK, error) {
+	const prefix = "failed to reshare ttbe keys"
+	dealers, newIDs, err := reshareIDs(tsk, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	if tsk != nil {
+		fu := shamir.GenRandPoly(cfg.NewT, tsk.u, bn.Order)
+		fv := shamir.GenRandPoly(cfg.NewT, tsk.v, bn.Order)
+		cm := &reshareCommitMsg{
+			U: shamir.Commit(fu, tpk.H1, tpk.H2),
+			V: shamir.Commit(fv, tpk.V1, tpk.V2),
+		}
+		if err = tr.Send(&Message{To: Broadcast, Round: reshareRoundCommit, Payload: cm}); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+		for _, id := range newIDs {
+			x := new(big.Int).SetUint64(id)
+			sm := &reshareShareMsg{shamir.EvalPoly(fu, x, bn.Order), shamir.EvalPoly(fv, x, bn.Order)}
+			if err = tr.Send(&Message{To: id, Round: reshareRoundShare, Payload: sm}); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+			}
+		}
+	}
+
+	commits, err := receiveReshareCommits(tpk, cfg, dealers, tr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	xs := make([]*big.Int, len(dealers))
+	for i, id := range dealers {
+		xs[i] = new(big.Int).SetUint64(id)
+	}
+	lags := make([]*big.Int, len(dealers))
+	for i := range xs {
+		lags[i] = shamir.LagCoeff(xs[i], xs, bn.Order)
+	}
+
+	var newTSK *TSK
+	if containsID(newIDs, cfg.ID) {
+		msgs, err := receiveFrom(tr, reshareRoundShare, dealers)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+		x := new(big.Int).SetUint64(cfg.ID)
+		newTSK = &TSK{cfg.ID, big.NewInt(0), big.NewInt(0)}
+		for i, msg := range msgs {
+			m, ok := msg.Payload.(*reshareShareMsg)
+			if !ok || m.U == nil || m.V == nil ||
+				shamir.VerifyShare(shamir.NewShare(x, m.U), commits[i].U, tpk.H1) != nil ||
+				shamir.VerifyShare(shamir.NewShare(x, m.V), commits[i].V, tpk.V1) != nil {
+				return nil, nil, fmt.Errorf("%s: %w", prefix, invalidMsgErr(msg))
+			}
+			newTSK.u = utils.AddMod(newTSK.u, utils.MulMod(lags[i], m.U))
+			newTSK.v = utils.AddMod(newTSK.v, utils.MulMod(lags[i], m.V))
+		}
+	}
+
+	newTVKs := make([]*TVK, len(cfg.NewIDs))
+	for k, id := range cfg.NewIDs {
+		x := new(big.Int).SetUint64(id)
+		tvk := &TVK{
+			id,
+			utils.NewG1(big.NewInt(0)), utils.NewG1(big.NewInt(0)),
+			utils.NewG2(big.NewInt(0)), utils.NewG2(big.NewInt(0)),
+		}
+		for i, c := range commits {
+			tvk.u1.Add(tvk.u1, new(bn.G1).ScalarMult(c.U.Eval(x), lags[i]))
+			tvk.v1.Add(tvk.v1, new(bn.G1).ScalarMult(c.V.Eval(x), lags[i]))
+			tvk.u2.Add(tvk.u2, new(bn.G2).ScalarMult(c.U.EvalG2(x), lags[i]))
+			tvk.v2.Add(tvk.v2, new(bn.G2).ScalarMult(c.V.EvalG2(x), lags[i]))
+		}
+		newTVKs[k] = tvk
+	}
+
+	return newTSK, newTVKs, nil
+}
+
+// receiveReshareCommits 接收并验证所有旧审计者的承诺，承诺需与基一致，
+// 且常数项与该审计者的旧TVK一致
+func receiveReshareCommits(tpk *TPK, cfg *ReshareConfig, dealers []uint64, tr Transport) ([]*reshareCommitMsg, error) {
+	oldTVKs := make(map[uint64]*TVK, len(cfg.OldTVKs))
+	for _, tvk := range cfg.OldTVKs {
+		oldTVKs[tvk.id] = tvk
+	}
+	msgs, err := receiveFrom(tr, reshareRoundCommit, dealers)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]*reshareCommitMsg, len(msgs))
+	for i, msg := range msgs {
+		m, ok := msg.Payload.(*reshareCommitMsg)
+		if !ok || !validReshareCommit(m.U, tpk.H1, tpk.H2, cfg.NewT) || !validReshareCommit(m.V, tpk.V1, tpk.V2, cfg.NewT) {
+			return nil, invalidMsgErr(msg)
+		}
+		// 承诺与旧TVK由多个参与方共享，而Marshal会修改点的内部表示，因此在副本上比较
+		tvk := oldTVKs[msg.From]
+		if !utils.Equals(new(bn.G1).Set(m.U.Secret()), new(bn.G1).Set(tvk.u1)) ||
+			!utils.Equals(new(bn.G1).Set(m.V.Secret()), new(bn.G1).Set(tvk.v1)) {
+			return nil, invalidMsgErr(msg)
+		}
+		commits[i] = m
+	}
+
+	return commits, nil
+}
+
+func validReshareCommit(c *shamir.Commitment, g1 *bn.G1, g2 *bn.G2, t uint64) bool {
+	return c.Validate(g1, g2) == nil && c.G2s != nil && uint64(c.Threshold()) == t
+}
+
+// reshareIDs 检查重新分享的参数，返回升序排列的旧审计者id与新审计者id
+func reshareIDs(tsk *TSK, cfg *ReshareConfig) (dealers, newIDs []uint64, err error) {
+	if cfg == nil || cfg.OldT == 0 || uint64(len(cfg.OldTVKs)) < cfg.OldT ||
+		cfg.NewT == 0 || uint64(len(cfg.NewIDs)) < cfg.NewT {
+		return nil, nil, fmt.Errorf("%w, need at least t tvks and new ids", ErrIllegalReshareParams)
+	}
+	if dealers, err = tvkIDs(cfg.OldTVKs); err != nil {
+		return nil, nil, fmt.Errorf("%w, %v", ErrIllegalReshareParams, err)
+	}
+	if newIDs, err = sortIDs(cfg.NewIDs); err != nil {
+		return nil, nil, fmt.Errorf("%w, %v", ErrIllegalReshareParams, err)
+	}
+	isDealer := containsID(dealers, cfg.ID)
+	if isDealer != (tsk != nil) || tsk != nil && tsk.id != cfg.ID {
+		return nil, nil, fmt.Errorf("%w, tsk does not match party %d", ErrIllegalReshareParams, cfg.ID)
+	}
+	if !isDealer && !containsID(newIDs, cfg.ID) {
+		return nil, nil, fmt.Errorf("%w, party %d is in neither committee", ErrIllegalReshareParams, cfg.ID)
+	}
+
+	return dealers, newIDs, nil
+}
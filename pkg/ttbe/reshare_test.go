@@ -0,0 +1,246 @@
+package ttbe
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
+	"github.com/TomCN0803/taat-lib/pkg/shamir"
+	bn "github.com/cloudflare/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReshare(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		dealers []int // 参与重新分享的旧审计者在params.TSKs中的下标
+		newIDs  []uint64
+		newT    uint64
+		inG1    bool
+	}{
+		{
+			"larger committee with higher threshold",
+			[]int{0, 2, 4},
+			[]uint64{5, 6, 7, 8, 9, 10, 11},
+			4,
+			true,
+		},
+		{
+			"smaller committee with lower threshold",
+			[]int{1, 2, 3, 4},
+			[]uint64{2, 6},
+			2,
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			params, err := Setup(5, 3)
+			require.NoError(t, err)
+			var msg any
+			if tc.inG1 {
+				_, msg, _ = bn.RandomG1(rand.Reader)
+			} else {
+				_, msg, _ = bn.RandomG2(rand.Reader)
+			}
+			tag := big.NewInt(11)
+			cttbe, _, _, err := Encrypt(params.TPK, tag, msg)
+			require.NoError(t, err)
+
+			tsks, tvkss, errs := runReshareParties(params, tc.dealers, tc.newIDs, tc.newT, nil)
+			for _, err := range errs {
+				require.NoError(t, err)
+			}
+			tvks := tvkss[tc.newIDs[0]]
+			for id, res := range tvkss {
+				require.Len(t, res, len(tc.newIDs))
+				for k, tvk := range res {
+					require.Equal(t, tc.newIDs[k], tvk.ID())
+					require.Equal(t, tvks[k].Marshal(), tvk.Marshal(), "party %d", id)
+				}
+			}
+
+			// 使用新委员会中的最后newT个审计者解密
+			newTVKs := make([]*TVK, 0, tc.newT)
+			clues := make([]*AudClue, 0, tc.newT)
+			for k := len(tc.newIDs) - int(tc.newT); k < len(tc.newIDs); k++ {
+				tsk := tsks[tc.newIDs[k]]
+				require.NotNil(t, tsk)
+				require.Equal(t, tc.newIDs[k], tsk.ID())
+				clue, err := ShareAudClue(params.TPK, tag, cttbe, tsk)
+				require.NoError(t, err)
+				require.True(t, IsValidAudClue(params.TPK, tag, cttbe, tvks[k], clue))
+				newTVKs = append(newTVKs, tvks[k])
+				clues = append(clues, clue)
+			}
+			got, err := Combine(params.TPK, tag, cttbe, newTVKs, clues)
+			require.NoError(t, err)
+			requireElemEqual(t, tc.inG1, msg, got, true)
+
+			// 少于newT个审计者无法解密
+			got, err = Combine(params.TPK, tag, cttbe, newTVKs[1:], clues[1:])
+			require.NoError(t, err)
+			requireElemEqual(t, tc.inG1, msg, got, false)
+		})
+	}
+}
+
+func TestRunReshareIllegalParams(t *testing.T) {
+	t.Parallel()
+
+	params, err := Setup(3, 2)
+	require.NoError(t, err)
+	testCases := []struct {
+		name string
+		tsk  *TSK
+		cfg  *ReshareConfig
+	}{
+		{
+			"nil config",
+			params.TSKs[0],
+			nil,
+		},
+		{
+			"too few dealers",
+			params.TSKs[0],
+			&ReshareConfig{1, params.TVKs[:1], 2, []uint64{4, 5}, 2},
+		},
+		{
+			"too few new ids",
+			params.TSKs[0],
+			&ReshareConfig{1, params.TVKs, 2, []uint64{4}, 2},
+		},
+		{
+			"duplicate new ids",
+			params.TSKs[0],
+			&ReshareConfig{1, params.TVKs, 2, []uint64{4, 4}, 2},
+		},
+		{
+			"dealer without tsk",
+			nil,
+			&ReshareConfig{1, params.TVKs, 2, []uint64{4, 5}, 2},
+		},
+		{
+			"tsk of another party",
+			params.TSKs[1],
+			&ReshareConfig{1, params.TVKs, 2, []uint64{4, 5}, 2},
+		},
+		{
+			"party in neither committee",
+			nil,
+			&ReshareConfig{6, params.TVKs, 2, []uint64{4, 5}, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := RunReshare(params.TPK, tc.tsk, tc.cfg, NewLocalNetwork(6).Endpoint(1))
+			require.ErrorIs(t, err, ErrIllegalReshareParams)
+		})
+	}
+}
+
+func TestRunReshareCheatingDealer(t *testing.T) {
+	t.Parallel()
+
+	params, err := Setup(3, 2)
+	require.NoError(t, err)
+	testCases := []struct {
+		name   string
+		round  int
+		tamper func(payload any)
+	}{
+		{
+			"bad share",
+			reshareRoundShare,
+			func(payload any) {
+				m := payload.(*reshareShareMsg)
+				m.U = utils.AddMod(m.U, big.NewInt(1))
+			},
+		},
+		{
+			"sharing another secret",
+			reshareRoundCommit,
+			func(payload any) {
+				m := payload.(*reshareCommitMsg)
+				coeffs := shamir.GenRandPoly(uint64(m.V.Threshold()), big.NewInt(1), bn.Order)
+				*m.V = *shamir.Commit(coeffs, params.TPK.V1, params.TPK.V2)
+			},
+		},
+	}
+
+	const cheater = 2
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			wrap := func(id uint64, tr Transport) Transport {
+				if id != cheater {
+					return tr
+				}
+				return &tamperTransport{tr, tc.round, tc.tamper}
+			}
+			_, _, errs := runReshareParties(params, []int{0, 1}, []uint64{4, 5, 6}, 2, wrap)
+			for _, id := range []uint64{4, 5, 6} {
+				require.ErrorIs(t, errs[id], ErrInvalidDKGMsg)
+				require.ErrorContains(t, errs[id], "from party 2")
+			}
+		})
+	}
+}
+
+// runReshareParties 在新的 LocalNetwork 上并发运行重新分享，dealers为参与的旧审计者在params.TSKs中的下标，
+// 返回值以参与方id为键
+func runReshareParties(params *Parameters, dealers []int, newIDs []uint64, newT uint64,
+	wrap func(id uint64, tr Transport) Transport) (map[uint64]*TSK, map[uint64][]*TVK, map[uint64]error) {
+	oldT := uint64(len(dealers))
+	oldTSKs := make(map[uint64]*TSK, len(dealers))
+	oldTVKs := make([]*TVK, 0, len(dealers))
+	for _, i := range dealers {
+		oldTSKs[params.TSKs[i].ID()] = params.TSKs[i]
+		oldTVKs = append(oldTVKs, params.TVKs[i])
+	}
+	ids := append([]uint64{}, newIDs...)
+	for id := range oldTSKs {
+		if !containsID(newIDs, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	ln := NewLocalNetworkWithIDs(ids)
+	tsks := make(map[uint64]*TSK, len(ids))
+	tvkss := make(map[uint64][]*TVK, len(ids))
+	errs := make(map[uint64]error, len(ids))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id uint64) {
+			defer wg.Done()
+			tr := ln.Endpoint(id)
+			if wrap != nil {
+				tr = wrap(id, tr)
+			}
+			cfg := &ReshareConfig{id, oldTVKs, oldT, newIDs, newT}
+			tsk, tvks, err := RunReshare(params.TPK, oldTSKs[id], cfg, tr)
+			if err != nil {
+				ln.Abort(err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			tsks[id], tvkss[id], errs[id] = tsk, tvks, err
+		}(id)
+	}
+	wg.Wait()
+
+	return tsks, tvkss, errs
+}
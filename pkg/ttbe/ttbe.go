@@ -1,7 +1,6 @@
 package ttbe
 
 import (
-	"bytes"
 	"crypto/rand"
 	"errors"
 	"fmt"
@@ -16,6 +15,8 @@ var (
 	ErrInvalidCttbe                = errors.New("invalid TTBE cipher text")
 	ErrUnequalLenOfTVKsAndAudClues = errors.New("unequal length of tvks and audClues")
 	ErrEmptyTVKsOrAudClues         = errors.New("tvks or audClues must not be empty")
+	ErrDuplicateAudClue            = errors.New("duplicate audit clue id")
+	ErrNotEnoughValidClues         = errors.New("not enough valid audit clues")
 )
 
 // Setup 初始化TTBE参数，n为审计者的数量，t为门限阈值
@@ -119,6 +120,7 @@ func Encrypt(tpk *TPK, tag *big.Int, m any) (cttbe *Cttbe, r1 *big.Int, r2 *big.
 
 // Combine 根据线索恢复出cttbe对应的明文
 // tvks和clues数组要保持一致的对应顺序,且数量必须大于等于解密阈值t，否则会出错
+// cttbe只验证一次，所有线索的验证等式合并为一个配对乘积，仅在其不成立时才逐个验证以找出无效的线索
 func Combine(tpk *TPK, tag *big.Int, cttbe *Cttbe, tvks []*TVK, clues []*AudClue) (result any, err error) {
 	if len(tvks) == 0 || len(clues) == 0 {
 		return nil, ErrEmptyTVKsOrAudClues
@@ -130,6 +132,95 @@ func Combine(tpk *TPK, tag *big.Int, cttbe *Cttbe, tvks []*TVK, clues []*AudClue
 		return nil, ErrInvalidCttbe
 	}
 
+	if !areValidClues(tpk, cttbe, tvks, clues) {
+		for i, ac := range clues {
+			if !areValidClues(tpk, cttbe, tvks[i:i+1], clues[i:i+1]) {
+				return nil, fmt.Errorf("invalid audit clue of id %d", ac.id)
+			}
+		}
+	}
+
+	return combineClues(cttbe, clues)
+}
+
+// CombineReport 记录 CombineRobust 对各审计线索的处理结果
+type CombineReport struct {
+	Used     []uint64 // 用于恢复明文的线索的审计者id
+	Invalid  []uint64 // 提交了无效线索或者缺少对应TVK的审计者id
+	NilClues []int    // clues中为nil的元素的下标，这些元素被跳过
+	NilTVKs  []int    // tvks中为nil的元素的下标，这些元素被跳过
+}
+
+// CombineRobust 根据线索恢复出cttbe对应的明文，tvks按id与clues对应，不要求顺序一致。
+// 与 Combine 不同，无效的线索会被过滤并记录在返回的报告中，只要剩余的有效线索不少于门限t即可解密，
+// 有效线索不足时返回 ErrNotEnoughValidClues 以及记录了作恶审计者的报告，clues中id重复时返回 ErrDuplicateAudClue。
+// clues与tvks中的nil元素被跳过，其下标记录在报告中
+func CombineRobust(tpk *TPK, tag *big.Int, cttbe *Cttbe, tvks []*TVK, clues []*AudClue, t uint64) (any, *CombineReport, error) {
+	if t == 0 || len(tvks) == 0 || len(clues) == 0 {
+		return nil, nil, ErrEmptyTVKsOrAudClues
+	}
+	if !IsValidEnc(tpk, tag, cttbe) {
+		return nil, nil, ErrInvalidCttbe
+	}
+	report := new(CombineReport)
+	tvkByID := make(map[uint64]*TVK, len(tvks))
+	for i, tvk := range tvks {
+		if tvk == nil {
+			report.NilTVKs = append(report.NilTVKs, i)
+			continue
+		}
+		tvkByID[tvk.id] = tvk
+	}
+	nonNil := make([]*AudClue, 0, len(clues))
+	for i, clue := range clues {
+		if clue == nil {
+			report.NilClues = append(report.NilClues, i)
+			continue
+		}
+		nonNil = append(nonNil, clue)
+	}
+	clues = nonNil
+	seen := make(map[uint64]bool, len(clues))
+	for _, clue := range clues {
+		if seen[clue.id] {
+			return nil, nil, fmt.Errorf("%w %d", ErrDuplicateAudClue, clue.id)
+		}
+		seen[clue.id] = true
+	}
+
+	matched := make([]*AudClue, 0, len(clues))
+	matchedTVKs := make([]*TVK, 0, len(clues))
+	for _, clue := range clues {
+		if tvk, ok := tvkByID[clue.id]; ok {
+			matched = append(matched, clue)
+			matchedTVKs = append(matchedTVKs, tvk)
+		}
+	}
+	// 所有线索都有效时只需要验证一个合并的配对等式
+	allValid := len(clues) > 0 && len(matched) == len(clues) && areValidClues(tpk, cttbe, matchedTVKs, matched)
+	valid := make([]*AudClue, 0, len(clues))
+	for _, clue := range clues {
+		tvk, ok := tvkByID[clue.id]
+		if !allValid && (!ok || !areValidClues(tpk, cttbe, []*TVK{tvk}, []*AudClue{clue})) {
+			report.Invalid = append(report.Invalid, clue.id)
+			continue
+		}
+		valid = append(valid, clue)
+		report.Used = append(report.Used, clue.id)
+	}
+	if uint64(len(valid)) < t {
+		return nil, report, fmt.Errorf("%w, got %d, need %d", ErrNotEnoughValidClues, len(valid), t)
+	}
+	res, err := combineClues(cttbe, valid)
+	if err != nil {
+		return nil, report, err
+	}
+
+	return res, report, nil
+}
+
+// combineClues 以clues的id处的拉格朗日系数合并已验证的线索，恢复出明文
+func combineClues(cttbe *Cttbe, clues []*AudClue) (any, error) {
 	indices := make([]*big.Int, len(clues))
 	for i, clue := range clues {
 		indices[i] = big.NewInt(int64(clue.id))
@@ -141,10 +232,7 @@ func Combine(tpk *TPK, tag *big.Int, cttbe *Cttbe, tvks []*TVK, clues []*AudClue
 	} else {
 		den = utils.NewG2(big.NewInt(0))
 	}
-	for i, ac := range clues {
-		if !IsValidAudClue(tpk, tag, cttbe, tvks[i], ac) {
-			return nil, fmt.Errorf("invalid audit clue of id %d", ac.id)
-		}
+	for _, ac := range clues {
 		idx := big.NewInt(int64(ac.id))
 		coeff := shamir.LagCoeff(idx, indices, bn.Order)
 		c1, _ := utils.ScalarMult(ac.ac1, coeff)
@@ -157,7 +245,8 @@ func Combine(tpk *TPK, tag *big.Int, cttbe *Cttbe, tvks []*TVK, clues []*AudClue
 	return utils.Add(cttbe.C3, den)
 }
 
-// IsValidEnc 验证密文cttbe是否在给定tpk和tag下有效
+// IsValidEnc 验证密文cttbe是否在给定tpk和tag下有效，
+// 以随机数ρ合并两个验证等式：e(C1, U^tag*W)^ρ * e(C4, H)^-ρ * e(C2, U^tag*Z) * e(C5, V)^-1 == 1
 func IsValidEnc(tpk *TPK, tag *big.Int, cttbe *Cttbe) bool {
 	var u, v, w, h, z any
 	if !cttbe.InG1 {
@@ -165,19 +254,22 @@ func IsValidEnc(tpk *TPK, tag *big.Int, cttbe *Cttbe) bool {
 	} else {
 		u, v, w, h, z = tpk.U2, tpk.V2, tpk.W2, tpk.H2, tpk.Z2
 	}
+	rho, err := randBatchCoeff()
+	if err != nil {
+		return false
+	}
 
-	uw, _ := utils.ScalarMult(u, tag)
-	uw, _ = utils.Add(uw, w)
-	p1, _ := utils.Pair(cttbe.C1, uw)
-
-	uz, _ := utils.ScalarMult(u, tag)
-	uz, _ = utils.Add(uz, z)
-	p2, _ := utils.Pair(cttbe.C2, uz)
-
-	p4, _ := utils.Pair(cttbe.C4, h)
-	p5, _ := utils.Pair(cttbe.C5, v)
+	ut, _ := utils.ScalarMult(u, tag)
+	uw, _ := utils.Add(ut, w)
+	uz, _ := utils.Add(ut, z)
+	minusOne := new(big.Int).Sub(bn.Order, big.NewInt(1))
 
-	return bytes.Equal(p1.Marshal(), p4.Marshal()) && bytes.Equal(p2.Marshal(), p5.Marshal())
+	return utils.IsEProductOne([]*utils.EArg{
+		utils.NewEArg(cttbe.C1, uw, rho),
+		utils.NewEArg(cttbe.C4, h, utils.AddInv(rho, bn.Order)),
+		utils.NewEArg(cttbe.C2, uz, nil),
+		utils.NewEArg(cttbe.C5, v, minusOne),
+	})
 }
 
 // ShareAudClue return an auditing clue.
@@ -200,25 +292,72 @@ func ShareAudClue(tpk *TPK, tag *big.Int, cttbe *Cttbe, tsk *TSK) (*AudClue, err
 
 // IsValidAudClue 验证AudClue是否在给定tpk和tag下有效
 func IsValidAudClue(tpk *TPK, tag *big.Int, cttbe *Cttbe, tvk *TVK, clue *AudClue) bool {
-	// clue和cttbe需要在同一个群中
-	if !IsValidEnc(tpk, tag, cttbe) || clue.inG1 != cttbe.InG1 {
-		return false
+	return IsValidEnc(tpk, tag, cttbe) && areValidClues(tpk, cttbe, []*TVK{tvk}, []*AudClue{clue})
+}
+
+// areValidClues 批量验证clues[i]是否为tvks[i]对应的审计者对cttbe生成的有效线索，cttbe需已验证有效。
+// 每条线索需满足e(ac1_i, H) == e(C1, u_i)与e(ac2_i, V) == e(C2, v_i)，以随机数ρ_i、σ_i将其合并为
+//
+//	e(Σρ_i*ac1_i, H) * e(Σσ_i*ac2_i, V) * e(C1, -Σρ_i*u_i) * e(C2, -Σσ_i*v_i) == 1
+//
+// 因此无论线索数量多少都只需计算4个Miller loop与一次最终幂运算
+func areValidClues(tpk *TPK, cttbe *Cttbe, tvks []*TVK, clues []*AudClue) bool {
+	zero := big.NewInt(0)
+	// ac1、ac2与cttbe在同一个群中，u_i、v_i在另一个群中
+	a1, a2 := utils.ScalarBaseMult(cttbe.InG1, zero), utils.ScalarBaseMult(cttbe.InG1, zero)
+	u, v := utils.ScalarBaseMult(!cttbe.InG1, zero), utils.ScalarBaseMult(!cttbe.InG1, zero)
+	for i, clue := range clues {
+		// clue和cttbe需要在同一个群中
+		if clue.inG1 != cttbe.InG1 {
+			return false
+		}
+		rho, err := randBatchCoeff()
+		if err != nil {
+			return false
+		}
+		sigma, err := randBatchCoeff()
+		if err != nil {
+			return false
+		}
+
+		var ui, vi any
+		if cttbe.InG1 {
+			ui, vi = tvks[i].u2, tvks[i].v2
+		} else {
+			ui, vi = tvks[i].u1, tvks[i].v1
+		}
+		terms := []struct {
+			dst  *any
+			elem any
+			k    *big.Int
+		}{
+			{&a1, clue.ac1, rho},
+			{&a2, clue.ac2, sigma},
+			{&u, ui, utils.AddInv(rho, bn.Order)},
+			{&v, vi, utils.AddInv(sigma, bn.Order)},
+		}
+		for _, term := range terms {
+			e, err := utils.ScalarMult(term.elem, term.k)
+			if err != nil {
+				return false
+			}
+			if *term.dst, err = utils.Add(*term.dst, e); err != nil {
+				return false
+			}
+		}
 	}
 
-	var ui, vi any
-	var h, v any
+	var h, vv any
 	if cttbe.InG1 {
-		ui, vi = tvk.u2, tvk.v2
-		h, v = tpk.H2, tpk.V2
+		h, vv = tpk.H2, tpk.V2
 	} else {
-		ui, vi = tvk.u1, tvk.v1
-		h, v = tpk.H1, tpk.V1
+		h, vv = tpk.H1, tpk.V1
 	}
 
-	pi1, _ := utils.Pair(clue.ac1, h)
-	p1, _ := utils.Pair(cttbe.C1, ui)
-	pi2, _ := utils.Pair(clue.ac2, v)
-	p2, _ := utils.Pair(cttbe.C2, vi)
-
-	return bytes.Equal(pi1.Marshal(), p1.Marshal()) && bytes.Equal(pi2.Marshal(), p2.Marshal())
+	return utils.IsEProductOne([]*utils.EArg{
+		utils.NewEArg(a1, h, nil),
+		utils.NewEArg(a2, vv, nil),
+		utils.NewEArg(cttbe.C1, u, nil),
+		utils.NewEArg(cttbe.C2, v, nil),
+	})
 }
@@ -3,6 +3,7 @@ package ttbe
 import (
 	"crypto/rand"
 	"errors"
+	"math/big"
 	mrand "math/rand"
 	"testing"
 	"time"
@@ -105,6 +106,168 @@ func TestTTBEHappyPath(t *testing.T) {
 	}
 }
 
+func BenchmarkCombine(b *testing.B) {
+	params, err := Setup(10, 10)
+	require.NoError(b, err)
+	tag := big.NewInt(1)
+	cttbe, _, _, err := Encrypt(params.TPK, tag, params.TPK.W1)
+	require.NoError(b, err)
+	clues := make([]*AudClue, len(params.TSKs))
+	for i, tsk := range params.TSKs {
+		clues[i], err = ShareAudClue(params.TPK, tag, cttbe, tsk)
+		require.NoError(b, err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Combine(params.TPK, tag, cttbe, params.TVKs, clues)
+	}
+}
+
+func TestCombineInvalidClue(t *testing.T) {
+	t.Parallel()
+
+	params, err := Setup(4, 3)
+	require.NoError(t, err)
+	tag := big.NewInt(9)
+	cttbe, _, _, err := Encrypt(params.TPK, tag, params.TPK.W2)
+	require.NoError(t, err)
+	clues := make([]*AudClue, 3)
+	for i := range clues {
+		clues[i], err = ShareAudClue(params.TPK, tag, cttbe, params.TSKs[i])
+		require.NoError(t, err)
+	}
+	tvks := params.TVKs[:3]
+
+	res, err := Combine(params.TPK, tag, cttbe, tvks, clues)
+	require.NoError(t, err)
+	require.True(t, utils.Equals(params.TPK.W2, res.(*bn256.G2)))
+
+	// 交换两条线索的ac2后，单独验证时两条线索都无效
+	bad := []*AudClue{clues[0], {clues[1].id, false, clues[1].ac1, clues[2].ac2}, {clues[2].id, false, clues[2].ac1, clues[1].ac2}}
+	_, err = Combine(params.TPK, tag, cttbe, tvks, bad)
+	require.EqualError(t, err, "invalid audit clue of id 2")
+	require.False(t, IsValidAudClue(params.TPK, tag, cttbe, tvks[1], bad[1]))
+	require.True(t, IsValidAudClue(params.TPK, tag, cttbe, tvks[0], bad[0]))
+}
+
+func TestCombineRobust(t *testing.T) {
+	t.Parallel()
+
+	params, err := Setup(5, 3)
+	require.NoError(t, err)
+	_, msg, err := bn256.RandomG1(rand.Reader)
+	require.NoError(t, err)
+	tag := big.NewInt(5)
+	cttbe, _, _, err := Encrypt(params.TPK, tag, msg)
+	require.NoError(t, err)
+
+	clues := make([]*AudClue, 5)
+	forged := make([]*AudClue, 5)
+	for i, tsk := range params.TSKs {
+		clues[i], err = ShareAudClue(params.TPK, tag, cttbe, tsk)
+		require.NoError(t, err)
+		// 使用其他审计者的私钥伪造id为i+1的线索
+		other := params.TSKs[(i+1)%5]
+		forged[i], err = ShareAudClue(params.TPK, tag, cttbe, &TSK{tsk.id, other.u, other.v})
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		name     string
+		tvks     []*TVK
+		clues    []*AudClue
+		used     []uint64
+		invalid  []uint64
+		nilClues []int
+		nilTVKs  []int
+		err      error
+	}{
+		{
+			"all clues valid",
+			params.TVKs,
+			clues,
+			[]uint64{1, 2, 3, 4, 5},
+			nil,
+			nil,
+			nil,
+			nil,
+		},
+		{
+			"tolerate invalid clues",
+			params.TVKs,
+			[]*AudClue{clues[0], forged[1], clues[2], forged[3], clues[4]},
+			[]uint64{1, 3, 5},
+			[]uint64{2, 4},
+			nil,
+			nil,
+			nil,
+		},
+		{
+			"tvks in different order and clue without tvk",
+			[]*TVK{params.TVKs[4], params.TVKs[2], params.TVKs[1], params.TVKs[0]},
+			[]*AudClue{clues[3], clues[0], clues[1], clues[2]},
+			[]uint64{1, 2, 3},
+			[]uint64{4},
+			nil,
+			nil,
+			nil,
+		},
+		{
+			"not enough valid clues",
+			params.TVKs,
+			[]*AudClue{forged[0], clues[1], forged[2], clues[3]},
+			[]uint64{2, 4},
+			[]uint64{1, 3},
+			nil,
+			nil,
+			ErrNotEnoughValidClues,
+		},
+		{
+			"duplicate clue id",
+			params.TVKs,
+			[]*AudClue{clues[0], clues[1], clues[2], forged[1]},
+			nil,
+			nil,
+			nil,
+			nil,
+			ErrDuplicateAudClue,
+		},
+		{
+			"skip nil clues and tvks",
+			[]*TVK{params.TVKs[0], nil, params.TVKs[1], params.TVKs[2], params.TVKs[3]},
+			[]*AudClue{clues[0], nil, clues[1], nil, forged[2], clues[3]},
+			[]uint64{1, 2, 4},
+			[]uint64{3},
+			[]int{1, 3},
+			[]int{1},
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			res, report, err := CombineRobust(params.TPK, tag, cttbe, tc.tvks, tc.clues, 3)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.True(t, utils.Equals(msg, res.(*bn256.G1)))
+			}
+			if tc.used == nil && tc.invalid == nil {
+				require.Nil(t, report)
+				return
+			}
+			require.Equal(t, tc.used, report.Used)
+			require.Equal(t, tc.invalid, report.Invalid)
+			require.Equal(t, tc.nilClues, report.NilClues)
+			require.Equal(t, tc.nilTVKs, report.NilTVKs)
+		})
+	}
+}
+
 func examineShareAudClueCorrectness(t *testing.T, tsk *TSK, clue *AudClue, cttbe *Cttbe) {
 	require.NotNil(t, clue)
 	require.Equal(t, cttbe.InG1, clue.inG1)
@@ -1,6 +1,7 @@
 package ttbe
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/big"
@@ -9,7 +10,10 @@ import (
 	bn "github.com/cloudflare/bn256"
 )
 
-var ErrIllegalInG1Byte = errors.New("illegal rG1 byte, 0 for rG1 == false, 1 for rG1 == true")
+var (
+	ErrIllegalInG1Byte = errors.New("illegal rG1 byte, 0 for rG1 == false, 1 for rG1 == true")
+	ErrIllegalID       = errors.New("illegal auditor id, must be greater than 0")
+)
 
 // TPK TTBE公钥
 type TPK struct {
@@ -17,12 +21,88 @@ type TPK struct {
 	H2, U2, V2, W2, Z2 *bn.G2
 }
 
+// Marshal marshals TPK as H1 || U1 || V1 || W1 || Z1 || H2 || U2 || V2 || W2 || Z2.
+func (tpk *TPK) Marshal() []byte {
+	res := make([]byte, 0, 5*utils.G1SizeByte+5*utils.G2SizeByte)
+	for _, e := range []*bn.G1{tpk.H1, tpk.U1, tpk.V1, tpk.W1, tpk.Z1} {
+		res = append(res, new(bn.G1).Set(e).Marshal()...)
+	}
+	for _, e := range []*bn.G2{tpk.H2, tpk.U2, tpk.V2, tpk.W2, tpk.Z2} {
+		res = append(res, new(bn.G2).Set(e).Marshal()...)
+	}
+
+	return res
+}
+
+// Unmarshal reads from byte slice buff produced by TPK.Marshal and sets tpk to the result.
+func (tpk *TPK) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal tpk"
+	d := utils.NewDecoder(buff)
+	res := new(TPK)
+	var err error
+	for _, e := range []**bn.G1{&res.H1, &res.U1, &res.V1, &res.W1, &res.Z1} {
+		if *e, err = d.ReadG1(); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	for _, e := range []**bn.G2{&res.H2, &res.U2, &res.V2, &res.W2, &res.Z2} {
+		if *e, err = d.ReadG2(); err != nil {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	*tpk = *res
+
+	return nil
+}
+
 // TSK TTBE私钥
 type TSK struct {
 	id   uint64
 	u, v *big.Int
 }
 
+// ID returns the auditor id of tsk.
+func (tsk *TSK) ID() uint64 {
+	return tsk.id
+}
+
+// Marshal marshals TSK as id || u || v, id is a big endian uint64 and u, v are fixed-width scalars.
+func (tsk *TSK) Marshal() []byte {
+	res := make([]byte, 0, 8+2*utils.ScalarSizeByte)
+	res = binary.BigEndian.AppendUint64(res, tsk.id)
+	res = utils.AppendScalar(res, tsk.u)
+	res = utils.AppendScalar(res, tsk.v)
+
+	return res
+}
+
+// Unmarshal reads from byte slice buff produced by TSK.Marshal and sets tsk to the result.
+func (tsk *TSK) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal tsk"
+	d := utils.NewDecoder(buff)
+	id, err := readID(d)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	u, err := d.ReadScalar()
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	v, err := d.ReadScalar()
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	tsk.id, tsk.u, tsk.v = id, u, v
+
+	return nil
+}
+
 // TVK TTBE验证证密钥
 type TVK struct {
 	id     uint64
@@ -30,112 +110,107 @@ type TVK struct {
 	u2, v2 *bn.G2
 }
 
+// ID returns the auditor id of tvk.
+func (tvk *TVK) ID() uint64 {
+	return tvk.id
+}
+
+// Marshal marshals TVK as id || u1 || v1 || u2 || v2, id is a big endian uint64.
+func (tvk *TVK) Marshal() []byte {
+	res := make([]byte, 0, 8+2*utils.G1SizeByte+2*utils.G2SizeByte)
+	res = binary.BigEndian.AppendUint64(res, tvk.id)
+	res = append(res, new(bn.G1).Set(tvk.u1).Marshal()...)
+	res = append(res, new(bn.G1).Set(tvk.v1).Marshal()...)
+	res = append(res, new(bn.G2).Set(tvk.u2).Marshal()...)
+	res = append(res, new(bn.G2).Set(tvk.v2).Marshal()...)
+
+	return res
+}
+
+// Unmarshal reads from byte slice buff produced by TVK.Marshal and sets tvk to the result.
+func (tvk *TVK) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal tvk"
+	d := utils.NewDecoder(buff)
+	res := new(TVK)
+	var err error
+	if res.id, err = readID(d); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.u1, err = d.ReadG1(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.v1, err = d.ReadG1(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.u2, err = d.ReadG2(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.v2, err = d.ReadG2(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	*tvk = *res
+
+	return nil
+}
+
 // Cttbe TTBE密文
 type Cttbe struct {
 	InG1                   bool // true if in G1 and false in G2
 	C1, C2, C3, C4, C5, C6 any
 }
 
+// Marshal marshals Cttbe as inG1 || C1 || ... || C6.
 func (c *Cttbe) Marshal() []byte {
-	var res []byte
+	size := utils.G2SizeByte
 	if c.InG1 {
-		res = make([]byte, 0, utils.G1SizeByte*6+1)
-		res = append(res, 1)
-		res = append(res, c.C1.(*bn.G1).Marshal()...)
-		res = append(res, c.C2.(*bn.G1).Marshal()...)
-		res = append(res, c.C3.(*bn.G1).Marshal()...)
-		res = append(res, c.C4.(*bn.G1).Marshal()...)
-		res = append(res, c.C5.(*bn.G1).Marshal()...)
-		res = append(res, c.C6.(*bn.G1).Marshal()...)
-	} else {
-		res = make([]byte, 0, utils.G1SizeByte*6+1)
-		res = append(res, 0)
-		res = append(res, c.C1.(*bn.G2).Marshal()...)
-		res = append(res, c.C2.(*bn.G2).Marshal()...)
-		res = append(res, c.C3.(*bn.G2).Marshal()...)
-		res = append(res, c.C4.(*bn.G2).Marshal()...)
-		res = append(res, c.C5.(*bn.G2).Marshal()...)
-		res = append(res, c.C6.(*bn.G2).Marshal()...)
+		size = utils.G1SizeByte
+	}
+	res := make([]byte, 0, size*6+1)
+	res = utils.AppendBool(res, c.InG1)
+	for _, e := range []any{c.C1, c.C2, c.C3, c.C4, c.C5, c.C6} {
+		res, _ = utils.AppendElem(res, e)
 	}
 
 	return res
 }
 
 // Unmarshal reads from byte slice buff, converts it to *Cttbe and sets c to the converting result.
+// buff must be exactly the output of Cttbe.Marshal, and all elements must be valid points in the same group.
 func (c *Cttbe) Unmarshal(buff []byte) error {
-	if buff[0] == 1 {
-		c.InG1 = true
-	} else if buff[0] == 0 {
-		c.InG1 = false
-	} else {
-		return fmt.Errorf("failed to unmarshal buff: %w", ErrIllegalInG1Byte)
+	const prefix = "failed to unmarshal buff"
+	d := utils.NewDecoder(buff)
+	res, err := readCttbe(d)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
 	}
+	*c = *res
 
-	if c.InG1 {
-		c.C1 = new(bn.G1)
-		_, err := c.C1.(*bn.G1).Unmarshal(buff[1:65])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C2 = new(bn.G1)
-		_, err = c.C2.(*bn.G1).Unmarshal(buff[65:129])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C3 = new(bn.G1)
-		_, err = c.C3.(*bn.G1).Unmarshal(buff[129:193])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C4 = new(bn.G1)
-		_, err = c.C4.(*bn.G1).Unmarshal(buff[193:257])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C5 = new(bn.G1)
-		_, err = c.C5.(*bn.G1).Unmarshal(buff[257:321])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C6 = new(bn.G1)
-		_, err = c.C6.(*bn.G1).Unmarshal(buff[321:])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-	} else {
-		c.C1 = new(bn.G2)
-		_, err := c.C1.(*bn.G2).Unmarshal(buff[1:130])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C2 = new(bn.G2)
-		_, err = c.C2.(*bn.G2).Unmarshal(buff[130:259])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C3 = new(bn.G2)
-		_, err = c.C3.(*bn.G2).Unmarshal(buff[259:388])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C4 = new(bn.G2)
-		_, err = c.C4.(*bn.G2).Unmarshal(buff[388:517])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C5 = new(bn.G2)
-		_, err = c.C5.(*bn.G2).Unmarshal(buff[517:646])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
-		}
-		c.C6 = new(bn.G2)
-		_, err = c.C6.(*bn.G2).Unmarshal(buff[646:])
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal buff: %w", err)
+	return nil
+}
+
+// readCttbe reads a Cttbe written by Cttbe.Marshal.
+func readCttbe(d *utils.Decoder) (*Cttbe, error) {
+	inG1, err := d.ReadBool()
+	if errors.Is(err, utils.ErrIllegalBool) {
+		return nil, ErrIllegalInG1Byte
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := &Cttbe{InG1: inG1}
+	for _, e := range []*any{&res.C1, &res.C2, &res.C3, &res.C4, &res.C5, &res.C6} {
+		if *e, err = d.ReadElem(inG1); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return res, nil
 }
 
 // Equals check if c == cttbe.
@@ -171,6 +246,73 @@ type AudClue struct {
 	ac1, ac2 any
 }
 
+// ID returns the id of the auditor who shared clue.
+func (clue *AudClue) ID() uint64 {
+	return clue.id
+}
+
+// InG1 reports whether clue is in G1.
+func (clue *AudClue) InG1() bool {
+	return clue.inG1
+}
+
+// Marshal marshals AudClue as id || inG1 || ac1 || ac2, id is a big endian uint64.
+func (clue *AudClue) Marshal() []byte {
+	size := utils.G2SizeByte
+	if clue.inG1 {
+		size = utils.G1SizeByte
+	}
+	res := make([]byte, 0, 8+1+2*size)
+	res = binary.BigEndian.AppendUint64(res, clue.id)
+	res = utils.AppendBool(res, clue.inG1)
+	res, _ = utils.AppendElem(res, clue.ac1)
+	res, _ = utils.AppendElem(res, clue.ac2)
+
+	return res
+}
+
+// Unmarshal reads from byte slice buff produced by AudClue.Marshal and sets clue to the result.
+func (clue *AudClue) Unmarshal(buff []byte) error {
+	const prefix = "failed to unmarshal audit clue"
+	d := utils.NewDecoder(buff)
+	res := new(AudClue)
+	var err error
+	if res.id, err = readID(d); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.inG1, err = d.ReadBool(); err != nil {
+		if errors.Is(err, utils.ErrIllegalBool) {
+			err = ErrIllegalInG1Byte
+		}
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.ac1, err = d.ReadElem(res.inG1); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if res.ac2, err = d.ReadElem(res.inG1); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	if err = d.Finish(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	*clue = *res
+
+	return nil
+}
+
+// readID reads a non-zero auditor id.
+func readID(d *utils.Decoder) (uint64, error) {
+	id, err := d.ReadUint64()
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, ErrIllegalID
+	}
+
+	return id, nil
+}
+
 // Parameters TTBE初始化参数
 type Parameters struct {
 	TPK  *TPK
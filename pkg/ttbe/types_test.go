@@ -2,8 +2,10 @@ package ttbe
 
 import (
 	"crypto/rand"
+	"math/big"
 	"testing"
 
+	utils "github.com/TomCN0803/taat-lib/pkg/grouputils"
 	bn "github.com/cloudflare/bn256"
 	"github.com/stretchr/testify/require"
 )
@@ -41,6 +43,215 @@ func TestCttbeSerialize(t *testing.T) {
 	}
 }
 
+func TestCttbeUnmarshalMalformed(t *testing.T) {
+	t.Parallel()
+
+	g1Buff := mockRandomCttbe(true).Marshal()
+	g2Buff := mockRandomCttbe(false).Marshal()
+	testCases := []struct {
+		name string
+		buff []byte
+		err  error
+	}{
+		{
+			"empty",
+			nil,
+			utils.ErrShortBuffer,
+		},
+		{
+			"illegal group byte",
+			append([]byte{2}, g1Buff[1:]...),
+			ErrIllegalInG1Byte,
+		},
+		{
+			"truncated G1",
+			g1Buff[:len(g1Buff)-1],
+			utils.ErrShortBuffer,
+		},
+		{
+			"truncated G2",
+			g2Buff[:len(g2Buff)-1],
+			utils.ErrShortBuffer,
+		},
+		{
+			"point off curve",
+			append(append([]byte{}, g1Buff[:len(g1Buff)-1]...), g1Buff[len(g1Buff)-1]^1),
+			utils.ErrMalformedPoint,
+		},
+		{
+			"trailing bytes",
+			append(append([]byte{}, g1Buff...), 0),
+			utils.ErrTrailingBytes,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorIs(t, new(Cttbe).Unmarshal(tc.buff), tc.err)
+		})
+	}
+}
+
+func TestKeysAndCluesSerialize(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		inG1 bool
+	}{
+		{
+			"message in G1",
+			true,
+		},
+		{
+			"message in G2",
+			false,
+		},
+	}
+
+	params, err := Setup(5, 3)
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tpk := new(TPK)
+			require.NoError(t, tpk.Unmarshal(params.TPK.Marshal()))
+			require.Equal(t, params.TPK.Marshal(), tpk.Marshal())
+
+			var msg any
+			if tc.inG1 {
+				_, msg, _ = bn.RandomG1(rand.Reader)
+			} else {
+				_, msg, _ = bn.RandomG2(rand.Reader)
+			}
+			tag := big.NewInt(42)
+			cttbe, _, _, err := Encrypt(tpk, tag, msg)
+			require.NoError(t, err)
+
+			// 模拟审计者从持久化的TSK恢复，并将线索发送给合并者
+			tvks := make([]*TVK, 3)
+			clues := make([]*AudClue, 3)
+			for i := range clues {
+				tsk := new(TSK)
+				require.NoError(t, tsk.Unmarshal(params.TSKs[i+1].Marshal()))
+				require.Equal(t, params.TSKs[i+1].ID(), tsk.ID())
+				clue, err := ShareAudClue(tpk, tag, cttbe, tsk)
+				require.NoError(t, err)
+
+				clues[i] = new(AudClue)
+				require.NoError(t, clues[i].Unmarshal(clue.Marshal()))
+				require.Equal(t, tsk.ID(), clues[i].ID())
+				require.Equal(t, tc.inG1, clues[i].InG1())
+				tvks[i] = new(TVK)
+				require.NoError(t, tvks[i].Unmarshal(params.TVKs[i+1].Marshal()))
+				require.Equal(t, tsk.ID(), tvks[i].ID())
+			}
+			res, err := Combine(tpk, tag, cttbe, tvks, clues)
+			require.NoError(t, err)
+			if tc.inG1 {
+				require.True(t, utils.Equals(msg.(*bn.G1), res.(*bn.G1)))
+			} else {
+				require.True(t, utils.Equals(msg.(*bn.G2), res.(*bn.G2)))
+			}
+		})
+	}
+}
+
+func TestKeysAndCluesUnmarshalMalformed(t *testing.T) {
+	t.Parallel()
+
+	params, err := Setup(3, 2)
+	require.NoError(t, err)
+	tpkBuff := params.TPK.Marshal()
+	tskBuff := params.TSKs[0].Marshal()
+	tvkBuff := params.TVKs[0].Marshal()
+	cttbe, _, _, err := Encrypt(params.TPK, big.NewInt(1), params.TPK.H1)
+	require.NoError(t, err)
+	clue, err := ShareAudClue(params.TPK, big.NewInt(1), cttbe, params.TSKs[0])
+	require.NoError(t, err)
+	clueBuff := clue.Marshal()
+
+	withByte := func(b []byte, i int, v byte) []byte {
+		b = append([]byte{}, b...)
+		b[i] = v
+		return b
+	}
+
+	testCases := []struct {
+		name string
+		v    interface{ Unmarshal([]byte) error }
+		buff []byte
+		err  error
+	}{
+		{
+			"empty tpk",
+			new(TPK),
+			nil,
+			utils.ErrShortBuffer,
+		},
+		{
+			"tpk with trailing bytes",
+			new(TPK),
+			append(tpkBuff, 0),
+			utils.ErrTrailingBytes,
+		},
+		{
+			"tpk off curve",
+			new(TPK),
+			withByte(tpkBuff, utils.G1SizeByte-1, tpkBuff[utils.G1SizeByte-1]^1),
+			utils.ErrMalformedPoint,
+		},
+		{
+			"tsk with zero id",
+			new(TSK),
+			withByte(tskBuff, 7, 0),
+			ErrIllegalID,
+		},
+		{
+			"tsk scalar out of range",
+			new(TSK),
+			withByte(tskBuff, 8, 0xff),
+			utils.ErrScalarRange,
+		},
+		{
+			"truncated tsk",
+			new(TSK),
+			tskBuff[:len(tskBuff)-1],
+			utils.ErrShortBuffer,
+		},
+		{
+			"truncated tvk",
+			new(TVK),
+			tvkBuff[:len(tvkBuff)-1],
+			utils.ErrShortBuffer,
+		},
+		{
+			"clue with illegal group byte",
+			new(AudClue),
+			withByte(clueBuff, 8, 2),
+			ErrIllegalInG1Byte,
+		},
+		{
+			"clue with trailing bytes",
+			new(AudClue),
+			append(clueBuff, 0),
+			utils.ErrTrailingBytes,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorIs(t, tc.v.Unmarshal(tc.buff), tc.err)
+		})
+	}
+}
+
 func mockRandomCttbe(inG1 bool) *Cttbe {
 	if inG1 {
 		es := randomKG1Elems(6)